@@ -0,0 +1,263 @@
+package ecc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+/*
+BIP44 / BIP49 / BIP84 / BIP86 accounts
+
+	m / purpose' / coin type' / account' / change / address index
+
+purpose picks the address type (44 legacy P2PKH, 49 nested segwit, 84 native
+segwit, 86 taproot), coin type is 0 for mainnet and 1 for testnet, change is 0
+for receive addresses and 1 for change addresses.
+Discovery walks one chain and stops after gap limit unused addresses in a row.
+*/
+
+const (
+	PURPOSE_BIP44 uint32 = 44
+	PURPOSE_BIP49 uint32 = 49
+	PURPOSE_BIP84 uint32 = 84
+	PURPOSE_BIP86 uint32 = 86
+)
+
+const (
+	EXTERNAL_CHAIN uint32 = 0
+	INTERNAL_CHAIN uint32 = 1
+)
+
+const DefaultGapLimit = 20
+
+var (
+	ErrInvalidPath      = errors.New("invalid derivation path")
+	ErrUnknownPurpose   = errors.New("purpose must be 44, 49, 84 or 86")
+	ErrNotAccountKey    = errors.New("extended key is not at account depth (3) with a hardened index")
+	ErrInvalidGapLimit  = errors.New("gap limit must be positive")
+	ErrAddressIndexLeft = errors.New("no non-hardened address index left")
+)
+
+type DerivationPath []uint32
+
+// ParseDerivationPath parses "m/84'/0'/0'/0/5", hardened indexes end in ', h or H
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, ErrInvalidPath
+	}
+
+	parsed := DerivationPath{}
+	for _, part := range parts[1:] {
+		hardened := false
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			hardened = true
+			part = part[:len(part)-1]
+		}
+
+		// ParseUint would also take "+1", only plain digits are allowed
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return nil, ErrInvalidPath
+		}
+
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, ErrInvalidPath
+		}
+
+		if hardened {
+			index += HardenedKeyStart
+		}
+		parsed = append(parsed, uint32(index))
+	}
+
+	return parsed, nil
+}
+
+// String formats the path with ' for hardened indexes
+func (path DerivationPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+
+	for _, index := range path {
+		sb.WriteString("/")
+		if index >= HardenedKeyStart {
+			sb.WriteString(strconv.FormatUint(uint64(index-HardenedKeyStart), 10))
+			sb.WriteString("'")
+		} else {
+			sb.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+
+	return sb.String()
+}
+
+// DerivePath derives every index of path in turn starting from k
+func (k *ExtendedKey) DerivePath(path DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		child, err := key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// PurposeAddressType maps a BIP43 purpose to the address type of its accounts
+func PurposeAddressType(purpose uint32) (ADDRESS_TYPE, error) {
+	switch purpose {
+	case PURPOSE_BIP44:
+		return P2PKH, nil
+	case PURPOSE_BIP49:
+		return P2SH_P2WPKH, nil
+	case PURPOSE_BIP84:
+		return P2WPKH, nil
+	case PURPOSE_BIP86:
+		return P2TR, nil
+	default:
+		return 0, ErrUnknownPurpose
+	}
+}
+
+// AddressOf returns the address of type addrType for the compressed key,
+// taproot addresses are key path only (BIP86)
+func (p *Point) AddressOf(addrType ADDRESS_TYPE, testnet bool) (string, error) {
+	switch addrType {
+	case P2PKH:
+		return p.Address(true, testnet), nil
+	case P2SH_P2WPKH:
+		return p.P2SHP2WPKHAddress(testnet), nil
+	case P2WPKH:
+		return p.P2WPKHAddress(testnet), nil
+	case P2TR:
+		return p.P2TRAddress(nil, testnet)
+	default:
+		return "", ErrUnsupportedAddress
+	}
+}
+
+type Account struct {
+	key     *ExtendedKey
+	purpose uint32
+}
+
+// DiscoveredAddress is a used address found by Account.Discover
+type DiscoveredAddress struct {
+	Path    DerivationPath
+	Address string
+}
+
+// NewAccount derives m/purpose'/coin type'/index' from the master key, the
+// coin type follows the network of master
+func NewAccount(master *ExtendedKey, purpose uint32, index uint32) (*Account, error) {
+	if _, err := PurposeAddressType(purpose); err != nil {
+		return nil, err
+	}
+
+	coinType := uint32(0)
+	if master.testnet {
+		coinType = 1
+	}
+
+	path := DerivationPath{purpose + HardenedKeyStart, coinType + HardenedKeyStart, index + HardenedKeyStart}
+	key, err := master.DerivePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{key: key, purpose: purpose}, nil
+}
+
+// AccountFromKey wraps an account level key (usually an xpub from a watch
+// only wallet) so its addresses can be derived without the master key
+func AccountFromKey(accountKey *ExtendedKey, purpose uint32) (*Account, error) {
+	if _, err := PurposeAddressType(purpose); err != nil {
+		return nil, err
+	}
+
+	if accountKey.depth != 3 || accountKey.childNumber < HardenedKeyStart {
+		return nil, ErrNotAccountKey
+	}
+
+	return &Account{key: accountKey, purpose: purpose}, nil
+}
+
+func (a *Account) ExtendedKey() *ExtendedKey {
+	return a.key
+}
+
+func (a *Account) Purpose() uint32 {
+	return a.purpose
+}
+
+// Path is the full path of the account key, the coin type comes from the network
+func (a *Account) Path() DerivationPath {
+	coinType := uint32(0)
+	if a.key.testnet {
+		coinType = 1
+	}
+	return DerivationPath{a.purpose + HardenedKeyStart, coinType + HardenedKeyStart, a.key.childNumber}
+}
+
+// AddressKey derives the key of change / index under the account
+func (a *Account) AddressKey(change uint32, index uint32) (*ExtendedKey, error) {
+	return a.key.DerivePath(DerivationPath{change, index})
+}
+
+func (a *Account) Address(change uint32, index uint32) (string, error) {
+	key, err := a.AddressKey(change, index)
+	if err != nil {
+		return "", err
+	}
+
+	addrType, _ := PurposeAddressType(a.purpose)
+	return key.publicKey.AddressOf(addrType, a.key.testnet)
+}
+
+// Discover walks the addresses of the change chain and asks used about each
+// one, it stops after gapLimit unused addresses in a row and returns the used
+// addresses with the first index after the last used one
+func (a *Account) Discover(change uint32, gapLimit int, used func(address string) (bool, error)) ([]DiscoveredAddress, uint32, error) {
+	if gapLimit <= 0 {
+		return nil, 0, ErrInvalidGapLimit
+	}
+
+	found := []DiscoveredAddress{}
+	next := uint32(0)
+	gap := 0
+
+	for index := uint32(0); gap < gapLimit; index++ {
+		if index >= HardenedKeyStart {
+			return nil, 0, ErrAddressIndexLeft
+		}
+
+		address, err := a.Address(change, index)
+		if errors.Is(err, ErrBIP32InvalidChild) {
+			// BIP32 says to skip the index
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		isUsed, err := used(address)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if !isUsed {
+			gap++
+			continue
+		}
+
+		path := append(a.Path(), change, index)
+		found = append(found, DiscoveredAddress{Path: path, Address: address})
+		next = index + 1
+		gap = 0
+	}
+
+	return found, next, nil
+}
@@ -0,0 +1,176 @@
+package ecc
+
+import (
+	"strconv"
+	"testing"
+)
+
+// the reference addresses of BIP44, BIP49, BIP84 and BIP86 for the mnemonic
+// "abandon ... about" without passphrase
+func TestAccountReferenceAddresses(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	cases := []struct {
+		purpose uint32
+		testnet bool
+		change  uint32
+		index   uint32
+		path    string
+		address string
+	}{
+		{PURPOSE_BIP44, false, 0, 0, "m/44'/0'/0'/0/0", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{PURPOSE_BIP49, true, 0, 0, "m/49'/1'/0'/0/0", "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"},
+		{PURPOSE_BIP84, false, 0, 0, "m/84'/0'/0'/0/0", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{PURPOSE_BIP84, false, 0, 1, "m/84'/0'/0'/0/1", "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},
+		{PURPOSE_BIP84, false, 1, 0, "m/84'/0'/0'/1/0", "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+		{PURPOSE_BIP86, false, 0, 0, "m/86'/0'/0'/0/0", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		{PURPOSE_BIP86, false, 0, 1, "m/86'/0'/0'/0/1", "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh"},
+		{PURPOSE_BIP86, false, 1, 0, "m/86'/0'/0'/1/0", "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7"},
+	}
+
+	for _, c := range cases {
+		master, err := NewMasterKey(MnemonicToSeed(mnemonic, ""), c.testnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		account, err := NewAccount(master, c.purpose, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address, err := account.Address(c.change, c.index)
+		if err != nil || address != c.address {
+			t.Errorf("%s: %s (%v), want %s", c.path, address, err, c.address)
+		}
+
+		// the same address from the path and from the account xpub
+		path, err := ParseDerivationPath(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := master.DerivePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrType, _ := PurposeAddressType(c.purpose)
+		if address, _ := key.PublicKey().AddressOf(addrType, c.testnet); address != c.address {
+			t.Errorf("%s: DerivePath gives %s, want %s", c.path, address, c.address)
+		}
+
+		watchOnly, err := AccountFromKey(account.ExtendedKey().Neuter(), c.purpose)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address, _ := watchOnly.Address(c.change, c.index); address != c.address {
+			t.Errorf("%s: watch only account gives %s, want %s", c.path, address, c.address)
+		}
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	valid := []struct {
+		path string
+		want string
+	}{
+		{"m", "m"},
+		{"m/0", "m/0"},
+		{"m/84'/0h/0H/1/5", "m/84'/0'/0'/1/5"},
+		{"m/2147483647'/2147483647", "m/2147483647'/2147483647"},
+	}
+	for _, v := range valid {
+		path, err := ParseDerivationPath(v.path)
+		if err != nil || path.String() != v.want {
+			t.Errorf("ParseDerivationPath(%q) = %v, %v, want %s", v.path, path, err, v.want)
+		}
+	}
+
+	invalid := []string{"", "M/0", "0/1", "m/", "m//1", "m/0''", "m/'", "m/+1", "m/-1", "m/1x", "m/0x10", "m/2147483648", "m/4294967296'", "m/ 1"}
+	for _, path := range invalid {
+		if _, err := ParseDerivationPath(path); err != ErrInvalidPath {
+			t.Errorf("ParseDerivationPath(%q) = %v, want ErrInvalidPath", path, err)
+		}
+	}
+}
+
+func TestAccountDiscover(t *testing.T) {
+	master, err := NewMasterKey(MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", ""), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := NewAccount(master, PURPOSE_BIP84, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usedIndexes := map[uint32]bool{0: true, 3: true, 25: true}
+	usedAddresses := map[string]uint32{}
+	for index := range usedIndexes {
+		address, _ := account.Address(EXTERNAL_CHAIN, index)
+		usedAddresses[address] = index
+	}
+
+	cases := []struct {
+		gapLimit int
+		found    []uint32
+		next     uint32
+	}{
+		// 4 to 23 are 20 unused addresses in a row, 25 is never asked about
+		{20, []uint32{0, 3}, 4},
+		{21, []uint32{0, 3}, 4},
+		{22, []uint32{0, 3, 25}, 26},
+		{1, []uint32{0}, 1},
+	}
+	for _, c := range cases {
+		asked := 0
+		found, next, err := account.Discover(EXTERNAL_CHAIN, c.gapLimit, func(address string) (bool, error) {
+			asked++
+			_, ok := usedAddresses[address]
+			return ok, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if next != c.next || len(found) != len(c.found) {
+			t.Errorf("gap %d: found %v, next %d, want %v and %d", c.gapLimit, found, next, c.found, c.next)
+			continue
+		}
+		for i, f := range found {
+			if usedAddresses[f.Address] != c.found[i] || f.Path.String() != "m/84'/0'/0'/0/"+strconv.Itoa(int(c.found[i])) {
+				t.Errorf("gap %d: found %s at %s, want index %d", c.gapLimit, f.Address, f.Path, c.found[i])
+			}
+		}
+
+		// the walk stops gapLimit addresses after the last used one
+		if want := int(c.next) + c.gapLimit; asked != want {
+			t.Errorf("gap %d: asked about %d addresses, want %d", c.gapLimit, asked, want)
+		}
+	}
+
+	if _, _, err := account.Discover(EXTERNAL_CHAIN, 0, nil); err != ErrInvalidGapLimit {
+		t.Errorf("gap 0: %v, want ErrInvalidGapLimit", err)
+	}
+}
+
+func TestAccountErrors(t *testing.T) {
+	master, err := NewMasterKey(MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", ""), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewAccount(master, 45, 0); err != ErrUnknownPurpose {
+		t.Errorf("NewAccount purpose 45: %v, want ErrUnknownPurpose", err)
+	}
+	if _, err := AccountFromKey(master, PURPOSE_BIP84); err != ErrNotAccountKey {
+		t.Errorf("AccountFromKey master: %v, want ErrNotAccountKey", err)
+	}
+
+	// depth 3 but not hardened
+	key, err := master.DerivePath(DerivationPath{PURPOSE_BIP84 + HardenedKeyStart, HardenedKeyStart, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccountFromKey(key, PURPOSE_BIP84); err != ErrNotAccountKey {
+		t.Errorf("AccountFromKey non hardened: %v, want ErrNotAccountKey", err)
+	}
+}
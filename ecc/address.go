@@ -0,0 +1,158 @@
+package ecc
+
+import (
+	"errors"
+	"strings"
+)
+
+// Output script types an address can stand for
+type ADDRESS_TYPE int
+
+const (
+	// legacy, Base58 with version 0x00 (mainnet) or 0x6f (testnet)
+	P2PKH ADDRESS_TYPE = iota
+	// any script hash, Base58 with version 0x05 (mainnet) or 0xc4 (testnet)
+	P2SH
+	// segwit v0 key hash nested in a script hash, same encoding as P2SH
+	P2SH_P2WPKH
+	// native segwit v0 key hash, bech32 bc1q... / tb1q...
+	P2WPKH
+	// native segwit v0 script hash (32 bytes), bech32
+	P2WSH
+	// segwit v1 tweaked x-only key, bech32m bc1p... / tb1p...
+	P2TR
+	// any other witness version or program, kept for future soft forks
+	WITNESS_UNKNOWN
+)
+
+var (
+	ErrAddressLength  = errors.New("address payload must be a version byte and a 20 bytes hash")
+	ErrAddressVersion = errors.New("unknown address version byte")
+)
+
+// Address is a decoded address, Hash is the hash160 of the public key (P2PKH)
+// or of the redeem script (P2SH), for segwit addresses it is the witness program
+type Address struct {
+	Type           ADDRESS_TYPE
+	Testnet        bool
+	Hash           []byte
+	WitnessVersion int
+}
+
+// ParseAddress decodes a Base58Check P2PKH or P2SH address or a bech32/bech32m
+// segwit address, a nested segwit address can't be told apart from another
+// script hash so it comes back as P2SH
+func ParseAddress(address string) (*Address, error) {
+	lower := strings.ToLower(address)
+	if strings.HasPrefix(lower, "bc1") || strings.HasPrefix(lower, "tb1") {
+		return parseSegwitAddress(address, lower[:2] == "tb")
+	}
+
+	payload, err := DecodeBase58Checksum(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) != 21 {
+		return nil, ErrAddressLength
+	}
+
+	parsed := &Address{Hash: payload[1:]}
+
+	switch payload[0] {
+	case 0x00:
+		parsed.Type = P2PKH
+	case 0x6f:
+		parsed.Type, parsed.Testnet = P2PKH, true
+	case 0x05:
+		parsed.Type = P2SH
+	case 0xc4:
+		parsed.Type, parsed.Testnet = P2SH, true
+	default:
+		return nil, ErrAddressVersion
+	}
+
+	return parsed, nil
+}
+
+func parseSegwitAddress(address string, testnet bool) (*Address, error) {
+	version, program, err := DecodeSegwitAddress(segwitHRP(testnet), address)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &Address{Type: WITNESS_UNKNOWN, Testnet: testnet, Hash: program, WitnessVersion: version}
+
+	switch {
+	case version == 0 && len(program) == 20:
+		parsed.Type = P2WPKH
+	case version == 0 && len(program) == 32:
+		parsed.Type = P2WSH
+	case version == 1 && len(program) == 32:
+		parsed.Type = P2TR
+	}
+
+	return parsed, nil
+}
+
+func segwitHRP(testnet bool) string {
+	if testnet {
+		return "tb"
+	}
+	return "bc"
+}
+
+// String encodes the address back, P2SH_P2WPKH is encoded like P2SH
+func (a *Address) String() string {
+	var version byte
+
+	switch a.Type {
+	case P2PKH:
+		version = 0x00
+		if a.Testnet {
+			version = 0x6f
+		}
+	case P2SH, P2SH_P2WPKH:
+		version = 0x05
+		if a.Testnet {
+			version = 0xc4
+		}
+	default:
+		address, err := EncodeSegwitAddress(segwitHRP(a.Testnet), a.WitnessVersion, a.Hash)
+		if err != nil {
+			return ""
+		}
+		return address
+	}
+
+	return Base58Checksum(append([]byte{version}, a.Hash...))
+}
+
+// P2SHP2WPKHAddress returns the nested segwit address of the compressed key
+// the redeem script is 0x00 0x14 || hash160(SEC), the address is its hash160
+func (p *Point) P2SHP2WPKHAddress(testnet bool) string {
+	redeemScript := append([]byte{0x00, 0x14}, p.hash160(true)...)
+	address := &Address{Type: P2SH_P2WPKH, Testnet: testnet, Hash: Hash160(redeemScript)}
+
+	return address.String()
+}
+
+// P2WPKHAddress returns the native segwit v0 address of the compressed key
+func (p *Point) P2WPKHAddress(testnet bool) string {
+	address := &Address{Type: P2WPKH, Testnet: testnet, Hash: p.hash160(true)}
+
+	return address.String()
+}
+
+// P2TRAddress returns the taproot address of this internal key, merkleRoot is
+// nil for a key path only output (BIP86)
+func (p *Point) P2TRAddress(merkleRoot []byte, testnet bool) (string, error) {
+	Q, err := p.TaprootTweak(merkleRoot)
+	if err != nil {
+		return "", err
+	}
+
+	address := &Address{Type: P2TR, Testnet: testnet, Hash: Q.XOnly(), WitnessVersion: 1}
+
+	return address.String(), nil
+}
@@ -0,0 +1,71 @@
+package ecc
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		address  string
+		addrType ADDRESS_TYPE
+		testnet  bool
+		hash     string
+	}{
+		// hash160 of the compressed SEC of G
+		{"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", P2PKH, false, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r", P2PKH, true, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", P2SH, false, "b472a266d0bd89c13706a4132ccfb16f7c3b9fcb"},
+		{"2N9hLwkSqr1cPQAPxbrGVUjxyjD11G2e1he", P2SH, true, "b472a266d0bd89c13706a4132ccfb16f7c3b9fcb"},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", P2WPKH, false, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", P2WSH, true, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", P2TR, false, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+
+	for _, c := range cases {
+		a, err := ParseAddress(c.address)
+		if err != nil {
+			t.Errorf("%s: %v", c.address, err)
+			continue
+		}
+		if a.Type != c.addrType || a.Testnet != c.testnet || hex.EncodeToString(a.Hash) != c.hash {
+			t.Errorf("%s: %+v, want type %d, testnet %v, hash %s", c.address, a, c.addrType, c.testnet, c.hash)
+		}
+		if a.String() != c.address {
+			t.Errorf("%s: encodes back to %s", c.address, a.String())
+		}
+	}
+
+	G := GeneratorPoint()
+	if got := G.Address(true, false); got != cases[0].address {
+		t.Errorf("P2PKH of G = %s, want %s", got, cases[0].address)
+	}
+	if got := G.Address(true, true); got != cases[1].address {
+		t.Errorf("testnet P2PKH of G = %s, want %s", got, cases[1].address)
+	}
+	if got := G.Address(false, false); got != "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm" {
+		t.Errorf("uncompressed P2PKH of G = %s", got)
+	}
+}
+
+func TestParseAddressErrors(t *testing.T) {
+	hash := make([]byte, 20)
+
+	cases := []struct {
+		name    string
+		address string
+		err     error
+	}{
+		{"checksum", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMJ", ErrBase58Checksum},
+		{"invalid character", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAM0", ErrBase58InvalidChar},
+		{"short payload", Base58Checksum(append([]byte{0x00}, hash[1:]...)), ErrAddressLength},
+		{"long payload", Base58Checksum(append([]byte{0x00}, make([]byte, 32)...)), ErrAddressLength},
+		{"unknown version", Base58Checksum(append([]byte{0x30}, hash...)), ErrAddressVersion},
+		{"segwit checksum", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", ErrBech32Checksum},
+	}
+	for _, c := range cases {
+		if _, err := ParseAddress(c.address); err != c.err {
+			t.Errorf("%s: ParseAddress(%s) = %v, want %v", c.name, c.address, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ecc
+
+import (
+	"crypto/subtle"
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+/*
+Precomputed multiples of the generator point for k*G
+
+k*G is computed for every new key and every signature, G never changes so we
+build a table once (lazily, the first time it is needed) and reuse it:
+	table[i][j] = (2j + 1) * 16^i * G, i in 0 -> 64, j in 0 -> 7
+k is written in base 16 with odd digits only (regular recoding)
+	k = d0 + d1*16 + d2*16^2 + ... + d64*16^64, di in {-15, -13, ..., 13, 15}
+so k*G = table[0][d0] + table[1][d1] + ... + table[64][d64] with negative digits
+using -P = (x, -y). That is 64 additions and no doublings.
+None of the digits is 0 so we never add the identity point, and every lookup
+reads the whole row, so the work done does not depend on k.
+Recoding needs an odd k, when k is even we use k + n instead (n is odd and nG = 0).
+*/
+
+const (
+	baseWindowBits = 4
+	baseWindows    = 65
+	baseRowSize    = 1 << (baseWindowBits - 1)
+)
+
+// every entry is the affine (x, y) of the point
+var baseTable = sync.OnceValue(func() [][baseRowSize][2]s256Element {
+	table := make([][baseRowSize][2]s256Element, baseWindows)
+	base := GeneratorPoint().toJacobian().s256
+
+	for i := 0; i < baseWindows; i++ {
+		double := base.double()
+		current := base
+		for j := 0; j < baseRowSize; j++ {
+			table[i][j][0], table[i][j][1] = current.toAffine()
+			current = current.add(double)
+		}
+		// next row starts at 16^(i+1) * G
+		for j := 0; j < baseWindowBits; j++ {
+			base = base.double()
+		}
+	}
+
+	return table
+})
+
+// baseMul computes k*G with the precomputed table
+func baseMul(scalar *big.Int) *Point {
+	return baseMulJacobian(scalar).toAffine()
+}
+
+func baseMulJacobian(scalar *big.Int) *jacobianPoint {
+	if scalar == nil {
+		panic("Scalar can't be nil")
+	}
+
+	digits := baseDigits(scalar)
+	table := baseTable()
+	var result *s256Jacobian
+
+	for i, digit := range digits {
+		x, y := lookupBase(table[i], digit)
+		point := &s256Jacobian{x: x, y: y, z: s256One}
+
+		if result == nil {
+			result = point
+		} else {
+			result = result.add(point)
+		}
+	}
+
+	G := GeneratorPoint()
+	return &jacobianPoint{curve: G.curve, a: G.a, b: G.b, s256: result}
+}
+
+// lookupBase reads every entry of the row and keeps the one for |digit|, y
+// is negated for negative digits
+func lookupBase(row [baseRowSize][2]s256Element, digit int) (s256Element, s256Element) {
+	negative := uint64(uint(digit) >> (bits.UintSize - 1))
+	abs := (digit ^ -int(negative)) + int(negative)
+	index := abs >> 1
+
+	var x, y s256Element
+	for j := range row {
+		found := uint64(subtle.ConstantTimeEq(int32(j), int32(index)))
+		x = s256Select(found, row[j][0], x)
+		y = s256Select(found, row[j][1], y)
+	}
+
+	return x, s256Select(negative, y.neg(), y)
+}
+
+/*
+baseDigits does the regular recoding of k (k odd) on fixed-width limbs
+
+	for every digit but the last one:
+		d = (k mod 32) - 16  (odd, between -15 and 15)
+		k = (k - d) / 16     (stays odd)
+	last digit = k
+*/
+func baseDigits(scalar *big.Int) [baseWindows]int {
+	n := BitcoinN()
+	k := new(big.Int).Mod(scalar, n).FillBytes(make([]byte, 40))
+	kn := new(big.Int).Add(new(big.Int).Mod(scalar, n), n).FillBytes(make([]byte, 40))
+	subtle.ConstantTimeCopy(int(1-k[39]&1), k, kn)
+
+	var limbs [5]uint64
+	for i := range limbs {
+		for j := 0; j < 8; j++ {
+			limbs[i] |= uint64(k[39-i*8-j]) << (8 * j)
+		}
+	}
+
+	var digits [baseWindows]int
+	for i := 0; i < baseWindows-1; i++ {
+		d := int64(limbs[0]&0x1f) - 16
+		digits[i] = int(d)
+
+		// k = k - d, -d is sign extended over all the limbs
+		minusD := uint64(-d)
+		extension := uint64(d >> 63)
+		var carry uint64
+		limbs[0], carry = bits.Add64(limbs[0], minusD, 0)
+		for j := 1; j < len(limbs); j++ {
+			limbs[j], carry = bits.Add64(limbs[j], ^extension, carry)
+		}
+
+		// k = k / 16
+		for j := 0; j < len(limbs)-1; j++ {
+			limbs[j] = limbs[j]>>baseWindowBits | limbs[j+1]<<(64-baseWindowBits)
+		}
+		limbs[len(limbs)-1] >>= baseWindowBits
+	}
+	digits[baseWindows-1] = int(limbs[0])
+
+	return digits
+}
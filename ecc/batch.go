@@ -0,0 +1,185 @@
+package ecc
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+/*
+BatchVerifier collects the signatures of a whole transaction or block and
+checks them together instead of calling Point.Verify one by one.
+
+ECDSA signatures can't be combined into one equation (only r, the x of R, is
+in the signature) so they are verified independently, fanned out over one
+goroutine per CPU.
+
+Schnorr signatures are checked with one equation (BIP340 batch verification),
+every signature satisfies s*G = R + e*P, we pick random a1 = 1, a2, ..., au and check
+
+	(a1*s1 + ... + au*su) * G = a1*R1 + ... + au*Ru + (a1*e1)*P1 + ... + (au*eu)*Pu
+
+with one multi-scalar multiplication. The random factors stop an invalid
+signature from cancelling out another one. When the equation fails we go back
+to verifying them one by one to find which one is invalid.
+*/
+type BatchVerifier struct {
+	entries []batchEntry
+}
+
+type batchEntry struct {
+	pubKey  *Point
+	schnorr bool
+	// ECDSA
+	e   *Scalar
+	sig *Signature
+	// Schnorr
+	msg        []byte
+	schnorrSig *SchnorrSignature
+}
+
+// BatchVerifyError tells which entry (in the order they were added) failed
+type BatchVerifyError struct {
+	Index int
+}
+
+func (err *BatchVerifyError) Error() string {
+	return fmt.Sprintf("batch verification failed at index %d", err.Index)
+}
+
+func NewBatchVerifier() *BatchVerifier {
+	return &BatchVerifier{}
+}
+
+// Add queues an ECDSA signature of the message hash e made by pubKey
+func (bv *BatchVerifier) Add(pubKey *Point, e *Scalar, sig *Signature) {
+	bv.entries = append(bv.entries, batchEntry{pubKey: pubKey, e: e, sig: sig})
+}
+
+// AddSchnorr queues a BIP340 signature of msg made by the x-only key of pubKey
+func (bv *BatchVerifier) AddSchnorr(pubKey *Point, msg []byte, sig *SchnorrSignature) {
+	bv.entries = append(bv.entries, batchEntry{pubKey: pubKey, schnorr: true, msg: msg, schnorrSig: sig})
+}
+
+// missing tells whether the key, the hash or the signature of the entry is nil
+// (or a zero value), Verify reports it as failing instead of dereferencing it.
+// msg can be nil, BIP340 signs messages of any length
+func (entry *batchEntry) missing() bool {
+	if entry.pubKey == nil {
+		return true
+	}
+	if entry.schnorr {
+		return entry.schnorrSig == nil || entry.schnorrSig.r == nil || entry.schnorrSig.s == nil
+	}
+	return entry.e == nil || entry.sig == nil || entry.sig.r == nil || entry.sig.s == nil
+}
+
+func (bv *BatchVerifier) Len() int {
+	return len(bv.entries)
+}
+
+// Verify returns nil when every signature is valid, otherwise a
+// *BatchVerifyError with the lowest failing index
+func (bv *BatchVerifier) Verify() error {
+	if len(bv.entries) == 0 {
+		return nil
+	}
+
+	valid := make([]bool, len(bv.entries))
+	schnorrIndexes := []int{}
+	for i, entry := range bv.entries {
+		if entry.schnorr {
+			schnorrIndexes = append(schnorrIndexes, i)
+		}
+	}
+
+	if bv.verifySchnorrBatch(schnorrIndexes) {
+		for _, i := range schnorrIndexes {
+			valid[i] = true
+		}
+	}
+
+	workers := min(runtime.NumCPU(), len(bv.entries))
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < len(bv.entries); i += workers {
+				if valid[i] {
+					continue
+				}
+				entry := bv.entries[i]
+				if entry.missing() {
+					continue
+				}
+				if entry.schnorr {
+					valid[i] = entry.pubKey.VerifySchnorr(entry.msg, entry.schnorrSig)
+				} else {
+					valid[i] = entry.pubKey.Verify(entry.e, entry.sig)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i, ok := range valid {
+		if !ok {
+			return &BatchVerifyError{Index: i}
+		}
+	}
+
+	return nil
+}
+
+func (bv *BatchVerifier) verifySchnorrBatch(indexes []int) bool {
+	if len(indexes) == 0 {
+		return true
+	}
+
+	c := Secp256k1()
+	sSum := c.scalar(big.NewInt(0))
+	scalars := []*big.Int{}
+	points := []*Point{}
+
+	for count, i := range indexes {
+		entry := bv.entries[i]
+		if entry.missing() || entry.pubKey.x == nil || entry.pubKey.curve != Secp256k1() {
+			return false
+		}
+
+		P, err := liftX(entry.pubKey.x.num)
+		if err != nil {
+			return false
+		}
+
+		R, err := liftX(entry.schnorrSig.r.num)
+		if err != nil {
+			return false
+		}
+
+		a := c.scalar(big.NewInt(1))
+		if count > 0 {
+			random, err := rand.Int(rand.Reader, new(big.Int).Sub(c.n, big.NewInt(1)))
+			if err != nil {
+				return false
+			}
+			a = c.scalar(random.Add(random, big.NewInt(1)))
+		}
+
+		e := schnorrChallenge(entry.schnorrSig.r.num.FillBytes(make([]byte, 32)), P.XOnly(), entry.msg)
+		sSum = sSum.add(a.mul(entry.schnorrSig.s))
+
+		scalars = append(scalars, a.num, a.mul(e).num)
+		points = append(points, R, P)
+	}
+
+	// move the s side over: a1*R1 + ... + (au*eu)*Pu - (sum of ai*si)*G = identity
+	scalars = append(scalars, sSum.neg().num)
+	points = append(points, GeneratorPoint())
+
+	return multiScalarMulJacobian(scalars, points).isIdentity()
+}
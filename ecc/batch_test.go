@@ -0,0 +1,125 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// verifyBIP340Parses tells whether the key and the signature parse, the vector
+// is then rejected by the verification equation itself
+func verifyBIP340Parses(v bip340Vector) bool {
+	_, errP := ParseXOnly(v.publicKey)
+	_, errSig := ParseSchnorrSignature(v.sig)
+	return errP == nil && errSig == nil
+}
+
+func TestBatchVerifyBIP340Vectors(t *testing.T) {
+	bv := NewBatchVerifier()
+	var invalid *bip340Vector
+	for _, v := range loadBIP340Vectors(t) {
+		if v.valid {
+			P, _ := ParseXOnly(v.publicKey)
+			sig, _ := ParseSchnorrSignature(v.sig)
+			bv.AddSchnorr(P, v.msg, sig)
+		} else if invalid == nil && verifyBIP340Parses(v) {
+			invalid = &v
+		}
+	}
+
+	if err := bv.Verify(); err != nil {
+		t.Fatalf("valid vectors fail in a batch: %v", err)
+	}
+
+	// one invalid signature in the middle of valid ones
+	P, _ := ParseXOnly(invalid.publicKey)
+	sig, _ := ParseSchnorrSignature(invalid.sig)
+	badIndex := bv.Len()
+	bv.AddSchnorr(P, invalid.msg, sig)
+	bv.AddSchnorr(bv.entries[0].pubKey, bv.entries[0].msg, bv.entries[0].schnorrSig)
+
+	var batchErr *BatchVerifyError
+	if err := bv.Verify(); !errors.As(err, &batchErr) || batchErr.Index != badIndex {
+		t.Fatalf("Verify = %v, want a BatchVerifyError at index %d (vector %s)", err, badIndex, invalid.index)
+	}
+}
+
+func TestBatchVerifyECDSA(t *testing.T) {
+	c := Secp256k1()
+	bv := NewBatchVerifier()
+
+	for i := 1; i <= 6; i++ {
+		pk := MustPrivateKey(big.NewInt(int64(i * 1000)))
+		e := c.scalar(big.NewInt(int64(i)))
+		sig := pk.MustSign(e)
+
+		// entry 3 is checked against another message
+		if i == 4 {
+			e = c.scalar(big.NewInt(99))
+		}
+		bv.Add(pk.Public(), e, sig)
+	}
+
+	var batchErr *BatchVerifyError
+	if err := bv.Verify(); !errors.As(err, &batchErr) || batchErr.Index != 3 {
+		t.Fatalf("Verify = %v, want a BatchVerifyError at index 3", err)
+	}
+}
+
+func TestBatchVerifyEmpty(t *testing.T) {
+	if err := NewBatchVerifier().Verify(); err != nil {
+		t.Fatalf("empty batch: %v", err)
+	}
+}
+
+func TestBatchVerifyNilEntries(t *testing.T) {
+	c := Secp256k1()
+	pk := MustPrivateKey(big.NewInt(1000))
+	e := c.scalar(big.NewInt(1))
+	sig := pk.MustSign(e)
+	msg := make([]byte, 32)
+	schnorrSig, err := pk.SignSchnorr(msg, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		add  func(bv *BatchVerifier)
+	}{
+		{"nil ECDSA key", func(bv *BatchVerifier) { bv.Add(nil, e, sig) }},
+		{"nil hash", func(bv *BatchVerifier) { bv.Add(pk.Public(), nil, sig) }},
+		{"nil signature", func(bv *BatchVerifier) { bv.Add(pk.Public(), e, nil) }},
+		{"zero signature", func(bv *BatchVerifier) { bv.Add(pk.Public(), e, &Signature{}) }},
+		{"identity key", func(bv *BatchVerifier) { bv.Add(c.Identity(), e, sig) }},
+		{"nil Schnorr key", func(bv *BatchVerifier) { bv.AddSchnorr(nil, msg, schnorrSig) }},
+		{"nil Schnorr signature", func(bv *BatchVerifier) { bv.AddSchnorr(pk.Public(), msg, nil) }},
+		{"zero Schnorr signature", func(bv *BatchVerifier) { bv.AddSchnorr(pk.Public(), msg, &SchnorrSignature{}) }},
+	}
+
+	for _, v := range cases {
+		// the bad entry sits between valid ones of both kinds
+		bv := NewBatchVerifier()
+		bv.Add(pk.Public(), e, sig)
+		bv.AddSchnorr(pk.Public(), msg, schnorrSig)
+		v.add(bv)
+		bv.AddSchnorr(pk.Public(), msg, schnorrSig)
+		bv.Add(pk.Public(), e, sig)
+
+		var batchErr *BatchVerifyError
+		if err := bv.Verify(); !errors.As(err, &batchErr) || batchErr.Index != 2 {
+			t.Errorf("%s: Verify = %v, want a BatchVerifyError at index 2", v.name, err)
+		}
+	}
+
+	// a nil message is an empty one for BIP340
+	bv := NewBatchVerifier()
+	emptySig, err := pk.SignSchnorr(nil, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bv.AddSchnorr(pk.Public(), nil, emptySig)
+	if err := bv.Verify(); err != nil {
+		t.Errorf("nil message: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package ecc
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+Bech32 (BIP173) and Bech32m (BIP350)
+
+	hrp || '1' || data (5 bits per character) || 6 characters checksum
+
+The checksum is a BCH code over GF(32), the polymod of hrp expanded || data ||
+checksum has to be 1 for bech32 and 0x2bc830a3 for bech32m.
+Segwit addresses put the witness version (0 - 16) as first data character and
+the witness program (converted from 8 to 5 bits) after it, version 0 uses
+bech32, every other version uses bech32m.
+*/
+
+type BECH32_ENCODING int
+
+const (
+	BECH32 BECH32_ENCODING = iota
+	BECH32M
+)
+
+const (
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Const     = 1
+	bech32mConst    = 0x2bc830a3
+	bech32MaxLength = 90
+)
+
+var (
+	ErrBech32Length        = errors.New("bech32 string length is out of range")
+	ErrBech32InvalidChar   = errors.New("invalid bech32 character")
+	ErrBech32MixedCase     = errors.New("bech32 string mixes upper and lower case")
+	ErrBech32Separator     = errors.New("bech32 separator missing or misplaced")
+	ErrBech32Checksum      = errors.New("bech32 checksum mismatch")
+	ErrBech32Padding       = errors.New("bech32 data has invalid padding")
+	ErrWitnessVersion      = errors.New("witness version must be between 0 and 16")
+	ErrWitnessProgram      = errors.New("witness program length is invalid")
+	ErrSegwitHRP           = errors.New("segwit address has an unexpected human readable part")
+	ErrSegwitWrongEncoding = errors.New("witness version 0 needs bech32, later versions need bech32m")
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+// bech32HRPExpand gives the high bits of every hrp character, a zero, then the low bits
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+func bech32Checksum(hrp string, data []byte, encoding BECH32_ENCODING) []byte {
+	target := uint32(bech32Const)
+	if encoding == BECH32M {
+		target = bech32mConst
+	}
+
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ target
+
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(polymod>>(5*(5-i))) & 31
+	}
+	return checksum
+}
+
+// EncodeBech32 encodes data (5 bits values) under hrp, hrp must be lower case
+func EncodeBech32(hrp string, data []byte, encoding BECH32_ENCODING) string {
+	combined := append(append([]byte{}, data...), bech32Checksum(hrp, data, encoding)...)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, value := range combined {
+		sb.WriteByte(bech32Charset[value])
+	}
+	return sb.String()
+}
+
+// DecodeBech32 returns the lower case hrp, the data (5 bits values, without
+// the checksum) and which of bech32 and bech32m the checksum matched
+func DecodeBech32(s string) (string, []byte, BECH32_ENCODING, error) {
+	if len(s) < 8 || len(s) > bech32MaxLength {
+		return "", nil, 0, ErrBech32Length
+	}
+
+	hasLower, hasUpper := false, false
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return "", nil, 0, ErrBech32InvalidChar
+		}
+		hasLower = hasLower || (s[i] >= 'a' && s[i] <= 'z')
+		hasUpper = hasUpper || (s[i] >= 'A' && s[i] <= 'Z')
+	}
+	if hasLower && hasUpper {
+		return "", nil, 0, ErrBech32MixedCase
+	}
+	s = strings.ToLower(s)
+
+	// hrp is at least one character and the checksum is 6 characters
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, 0, ErrBech32Separator
+	}
+
+	hrp := s[:separator]
+	data := make([]byte, 0, len(s)-separator-1)
+	for i := separator + 1; i < len(s); i++ {
+		value := strings.IndexByte(bech32Charset, s[i])
+		if value < 0 {
+			return "", nil, 0, ErrBech32InvalidChar
+		}
+		data = append(data, byte(value))
+	}
+
+	var encoding BECH32_ENCODING
+	switch bech32Polymod(append(bech32HRPExpand(hrp), data...)) {
+	case bech32Const:
+		encoding = BECH32
+	case bech32mConst:
+		encoding = BECH32M
+	default:
+		return "", nil, 0, ErrBech32Checksum
+	}
+
+	return hrp, data[:len(data)-6], encoding, nil
+}
+
+// convertBits regroups data from fromBits to toBits values, without pad the
+// leftover bits must be fewer than fromBits and all zero
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxValue := uint32(1)<<toBits - 1
+	result := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, ErrBech32Padding
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil, ErrBech32Padding
+	}
+
+	return result, nil
+}
+
+// EncodeSegwitAddress encodes a witness program, hrp is "bc" for mainnet and "tb" for testnet
+func EncodeSegwitAddress(hrp string, version int, program []byte) (string, error) {
+	if err := checkWitnessProgram(version, program); err != nil {
+		return "", err
+	}
+
+	encoding := BECH32M
+	if version == 0 {
+		encoding = BECH32
+	}
+
+	data, _ := convertBits(program, 8, 5, true)
+	return EncodeBech32(hrp, append([]byte{byte(version)}, data...), encoding), nil
+}
+
+// DecodeSegwitAddress returns the witness version and program of address, its hrp must be hrp
+func DecodeSegwitAddress(hrp string, address string) (int, []byte, error) {
+	gotHRP, data, encoding, err := DecodeBech32(address)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if gotHRP != hrp {
+		return 0, nil, ErrSegwitHRP
+	}
+
+	if len(data) < 1 {
+		return 0, nil, ErrWitnessProgram
+	}
+
+	version := int(data[0])
+	if version > 16 {
+		return 0, nil, ErrWitnessVersion
+	}
+
+	if (version == 0) != (encoding == BECH32) {
+		return 0, nil, ErrSegwitWrongEncoding
+	}
+
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := checkWitnessProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+
+	return version, program, nil
+}
+
+func checkWitnessProgram(version int, program []byte) error {
+	if version < 0 || version > 16 {
+		return ErrWitnessVersion
+	}
+
+	if len(program) < 2 || len(program) > 40 {
+		return ErrWitnessProgram
+	}
+
+	// version 0 is either P2WPKH or P2WSH
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return ErrWitnessProgram
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// valid strings of BIP173 (bech32) and BIP350 (bech32m)
+var bech32ValidStrings = []struct {
+	s        string
+	encoding BECH32_ENCODING
+}{
+	{"A12UEL5L", BECH32},
+	{"a12uel5l", BECH32},
+	{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", BECH32},
+	{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", BECH32},
+	{"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", BECH32},
+	{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", BECH32},
+	{"?1ezyfcl", BECH32},
+	{"A1LQFN3A", BECH32M},
+	{"a1lqfn3a", BECH32M},
+	{"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", BECH32M},
+	{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", BECH32M},
+	{"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8", BECH32M},
+	{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", BECH32M},
+	{"?1v759aa", BECH32M},
+}
+
+// invalid strings of BIP173 and BIP350, with the reason given there
+var bech32InvalidStrings = []struct {
+	s   string
+	err error
+}{
+	// BIP173
+	{"\x201nwldj5", ErrBech32InvalidChar}, // hrp character out of range
+	{"\x7f1axkwrx", ErrBech32InvalidChar}, // hrp character out of range
+	{"\x801eym55h", ErrBech32InvalidChar}, // hrp character out of range
+	{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", ErrBech32Length},
+	{"pzry9x0s0muk", ErrBech32Separator},   // no separator
+	{"1pzry9x0s0muk", ErrBech32Separator},  // empty hrp
+	{"x1b4n0q5v", ErrBech32InvalidChar},    // invalid data character
+	{"li1dgmt3", ErrBech32Separator},       // too short checksum
+	{"de1lg7wt\xff", ErrBech32InvalidChar}, // invalid character in checksum
+	{"A1G7SGD8", ErrBech32Checksum},        // checksum computed with the upper case hrp
+	{"10a06t8", ErrBech32Length},           // empty hrp
+	{"1qzzfhee", ErrBech32Separator},       // empty hrp
+	// BIP350
+	{"\x201xj0phk", ErrBech32InvalidChar}, // hrp character out of range
+	{"\x7f1g6xzxy", ErrBech32InvalidChar}, // hrp character out of range
+	{"\x801vctc34", ErrBech32InvalidChar}, // hrp character out of range
+	{"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4", ErrBech32Length},
+	{"qyrz8wqd2c9m", ErrBech32Separator},  // no separator
+	{"1qyrz8wqd2c9m", ErrBech32Separator}, // empty hrp
+	{"y1b0jsk6g", ErrBech32InvalidChar},   // invalid data character
+	{"lt1igcx5c0", ErrBech32InvalidChar},  // invalid data character
+	{"in1muywd", ErrBech32Separator},      // too short checksum
+	{"mm1crxm3i", ErrBech32InvalidChar},   // invalid character in checksum
+	{"au1s5cgom", ErrBech32InvalidChar},   // invalid character in checksum
+	{"M1VUXWEZ", ErrBech32Checksum},       // checksum computed with the upper case hrp
+	{"16plkw9", ErrBech32Length},          // empty hrp
+	{"1p2gdwpf", ErrBech32Separator},      // empty hrp
+	// mixed case
+	{"A12uEL5L", ErrBech32MixedCase},
+}
+
+func TestDecodeBech32Valid(t *testing.T) {
+	for _, v := range bech32ValidStrings {
+		hrp, data, encoding, err := DecodeBech32(v.s)
+		if err != nil {
+			t.Errorf("%q: %v", v.s, err)
+			continue
+		}
+		if encoding != v.encoding {
+			t.Errorf("%q: encoding %d, want %d", v.s, encoding, v.encoding)
+		}
+		if got := EncodeBech32(hrp, data, encoding); got != strings.ToLower(v.s) {
+			t.Errorf("%q: encodes back to %q", v.s, got)
+		}
+
+		// one changed character breaks the checksum
+		broken := []byte(strings.ToLower(v.s))
+		last := len(broken) - 1
+		broken[last] = bech32Charset[(strings.IndexByte(bech32Charset, broken[last])+1)%32]
+		if _, _, _, err := DecodeBech32(string(broken)); err != ErrBech32Checksum {
+			t.Errorf("%q: %v, want ErrBech32Checksum", broken, err)
+		}
+	}
+}
+
+func TestDecodeBech32Invalid(t *testing.T) {
+	for _, v := range bech32InvalidStrings {
+		if _, _, _, err := DecodeBech32(v.s); err != v.err {
+			t.Errorf("%q: %v, want %v", v.s, err, v.err)
+		}
+	}
+}
+
+// valid segwit addresses of BIP350 and their scriptPubKey
+var segwitValidAddresses = []struct {
+	address      string
+	scriptPubKey string
+}{
+	{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+	{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"BC1SW50QGDZ25J", "6002751e"},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+	{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+}
+
+// invalid segwit addresses of BIP350, then the ones of BIP173 that are still invalid
+var segwitInvalidAddresses = []struct {
+	address string
+	err     error
+}{
+	{"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut", ErrSegwitHRP},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", ErrSegwitWrongEncoding},
+	{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", ErrSegwitWrongEncoding},
+	{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", ErrSegwitWrongEncoding},
+	{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", ErrSegwitWrongEncoding},
+	{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", ErrSegwitWrongEncoding},
+	{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", ErrBech32InvalidChar},
+	{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", ErrWitnessVersion},
+	{"bc1pw5dgrnzv", ErrWitnessProgram},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", ErrWitnessProgram},
+	{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", ErrWitnessProgram},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", ErrBech32MixedCase},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", ErrBech32Padding},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", ErrBech32Padding},
+	{"bc1gmk9yu", ErrWitnessProgram},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", ErrSegwitWrongEncoding},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3pjxtptv", ErrBech32Padding},
+	{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7", ErrBech32MixedCase},
+}
+
+func TestSegwitAddressValid(t *testing.T) {
+	for _, v := range segwitValidAddresses {
+		hrp := strings.ToLower(v.address[:2])
+		version, program, err := DecodeSegwitAddress(hrp, v.address)
+		if err != nil {
+			t.Errorf("%s: %v", v.address, err)
+			continue
+		}
+
+		// OP_0 or OP_1 - OP_16, then the push of the program
+		opcode := byte(0)
+		if version > 0 {
+			opcode = byte(0x50 + version)
+		}
+		scriptPubKey := append([]byte{opcode, byte(len(program))}, program...)
+		if want, _ := hex.DecodeString(v.scriptPubKey); !bytes.Equal(scriptPubKey, want) {
+			t.Errorf("%s: scriptPubKey %x, want %s", v.address, scriptPubKey, v.scriptPubKey)
+		}
+
+		address, err := EncodeSegwitAddress(hrp, version, program)
+		if err != nil || address != strings.ToLower(v.address) {
+			t.Errorf("%s: encodes back to %s (%v)", v.address, address, err)
+		}
+	}
+}
+
+func TestSegwitAddressInvalid(t *testing.T) {
+	for _, v := range segwitInvalidAddresses {
+		hrp := "bc"
+		if strings.HasPrefix(strings.ToLower(v.address), "tb") {
+			hrp = "tb"
+		}
+
+		if _, _, err := DecodeSegwitAddress(hrp, v.address); err != v.err {
+			t.Errorf("%s: %v, want %v", v.address, err, v.err)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package ecc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+/*
+BIP32 hierarchical deterministic keys
+
+An extended key is a key plus a 32 bytes chain code, children are derived with
+	I = HMAC-SHA512(chain code, data), IL = I[:32], IR = I[32:]
+	data = 0x00 || ser256(k) || ser32(i) for a hardened child (i >= 2^31)
+	data = serP(K) || ser32(i) otherwise
+	child private key = IL + k mod n, child public key = IL*G + K
+	child chain code = IR
+Only the private key can derive hardened children. When IL >= n or the child
+key is zero / the identity the index is invalid and the caller moves to the
+next one (probability lower than 1 in 2^127).
+
+Serialization (78 bytes, Base58Check)
+	version (4) || depth (1) || parent fingerprint (4) || child number (4) ||
+	chain code (32) || 0x00 || ser256(k) or serP(K) (33)
+*/
+
+const HardenedKeyStart = 0x80000000
+
+var (
+	bip32XprvVersion = []byte{0x04, 0x88, 0xad, 0xe4}
+	bip32XpubVersion = []byte{0x04, 0x88, 0xb2, 0x1e}
+	bip32TprvVersion = []byte{0x04, 0x35, 0x83, 0x94}
+	bip32TpubVersion = []byte{0x04, 0x35, 0x87, 0xcf}
+)
+
+var (
+	ErrBIP32SeedLength        = errors.New("bip32 seed must be between 16 and 64 bytes")
+	ErrBIP32InvalidChild      = errors.New("bip32 child key is invalid, use the next index")
+	ErrBIP32HardenedPublic    = errors.New("cannot derive a hardened child from a public key")
+	ErrBIP32NotPrivate        = errors.New("extended key is public")
+	ErrBIP32DepthTooLarge     = errors.New("bip32 depth cannot go past 255")
+	ErrBIP32ChainCodeLength   = errors.New("bip32 chain code must be 32 bytes")
+	ErrBIP32KeyLength         = errors.New("serialized extended key must be 78 bytes")
+	ErrBIP32UnknownVersion    = errors.New("unknown extended key version")
+	ErrBIP32InvalidKey        = errors.New("extended key holds an invalid key")
+	ErrBIP32InvalidMasterInfo = errors.New("master key must have zero parent fingerprint and child number")
+)
+
+type ExtendedKey struct {
+	privateKey        *PrivateKey
+	publicKey         *Point
+	chainCode         []byte
+	depth             byte
+	parentFingerprint []byte
+	childNumber       uint32
+	testnet           bool
+}
+
+// NewMasterKey derives the master key from seed, I = HMAC-SHA512("Bitcoin seed", seed)
+func NewMasterKey(seed []byte, testnet bool) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrBIP32SeedLength
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+
+	secret, err := Secp256k1().NewScalar(new(big.Int).SetBytes(I[:32]))
+	if err != nil || secret.IsZero() {
+		return nil, ErrBIP32InvalidChild
+	}
+
+	privateKey := newPrivateKey(secret.num)
+
+	return &ExtendedKey{
+		privateKey:        privateKey,
+		publicKey:         privateKey.Q,
+		chainCode:         I[32:],
+		parentFingerprint: make([]byte, 4),
+		testnet:           testnet,
+	}, nil
+}
+
+// Child derives child index i, indexes from HardenedKeyStart on are hardened
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	if k.depth == 255 {
+		return nil, ErrBIP32DepthTooLarge
+	}
+
+	child := &ExtendedKey{
+		depth:             k.depth + 1,
+		parentFingerprint: k.Fingerprint(),
+		childNumber:       i,
+		testnet:           k.testnet,
+	}
+
+	if k.privateKey == nil {
+		publicKey, chainCode, err := k.publicKey.DeriveChild(k.chainCode, i)
+		if err != nil {
+			return nil, err
+		}
+		child.publicKey, child.chainCode = publicKey, chainCode
+		return child, nil
+	}
+
+	var data []byte
+	if i >= HardenedKeyStart {
+		data = append([]byte{0x00}, k.privateKey.d.Bytes()...)
+	} else {
+		_, data = k.publicKey.SEC(true)
+	}
+
+	IL, IR := bip32HMAC(k.chainCode, data, i)
+
+	tweak, err := Secp256k1().NewScalar(new(big.Int).SetBytes(IL))
+	if err != nil {
+		return nil, ErrBIP32InvalidChild
+	}
+
+	secret := tweak.add(k.privateKey.d)
+	if secret.IsZero() {
+		return nil, ErrBIP32InvalidChild
+	}
+
+	child.privateKey = newPrivateKey(secret.num)
+	child.publicKey = child.privateKey.Q
+	child.chainCode = IR
+
+	return child, nil
+}
+
+// DeriveChild is the public only derivation (CKDpub) of the non-hardened child
+// i of this key with chainCode, it returns the child key and its chain code
+func (p *Point) DeriveChild(chainCode []byte, i uint32) (*Point, []byte, error) {
+	if p.curve != Secp256k1() {
+		return nil, nil, ErrUnsupportedCurve
+	}
+
+	if i >= HardenedKeyStart {
+		return nil, nil, ErrBIP32HardenedPublic
+	}
+
+	if len(chainCode) != 32 {
+		return nil, nil, ErrBIP32ChainCodeLength
+	}
+
+	_, sec := p.SEC(true)
+	IL, IR := bip32HMAC(chainCode, sec, i)
+
+	tweak, err := Secp256k1().NewScalar(new(big.Int).SetBytes(IL))
+	if err != nil {
+		return nil, nil, ErrBIP32InvalidChild
+	}
+
+	child := baseMulJacobian(tweak.num).add(p.toJacobian()).toAffine()
+	if child.x == nil {
+		return nil, nil, ErrBIP32InvalidChild
+	}
+
+	return child, IR, nil
+}
+
+func bip32HMAC(chainCode []byte, data []byte, i uint32) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	binary.Write(mac, binary.BigEndian, i)
+	I := mac.Sum(nil)
+	return I[:32], I[32:]
+}
+
+// Neuter returns the extended public key of k
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	public := *k
+	public.privateKey = nil
+	return &public
+}
+
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.privateKey != nil
+}
+
+func (k *ExtendedKey) PrivateKey() (*PrivateKey, error) {
+	if k.privateKey == nil {
+		return nil, ErrBIP32NotPrivate
+	}
+	return k.privateKey, nil
+}
+
+func (k *ExtendedKey) PublicKey() *Point {
+	return k.publicKey
+}
+
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte{}, k.chainCode...)
+}
+
+func (k *ExtendedKey) Depth() byte {
+	return k.depth
+}
+
+func (k *ExtendedKey) ChildNumber() uint32 {
+	return k.childNumber
+}
+
+func (k *ExtendedKey) Testnet() bool {
+	return k.testnet
+}
+
+// Fingerprint is the first 4 bytes of hash160 of the compressed public key
+func (k *ExtendedKey) Fingerprint() []byte {
+	return k.publicKey.hash160(true)[:4]
+}
+
+func (k *ExtendedKey) ParentFingerprint() []byte {
+	return append([]byte{}, k.parentFingerprint...)
+}
+
+// String serializes k as xprv / xpub (mainnet) or tprv / tpub (testnet)
+func (k *ExtendedKey) String() string {
+	var version []byte
+	switch {
+	case k.privateKey != nil && k.testnet:
+		version = bip32TprvVersion
+	case k.privateKey != nil:
+		version = bip32XprvVersion
+	case k.testnet:
+		version = bip32TpubVersion
+	default:
+		version = bip32XpubVersion
+	}
+
+	buf := make([]byte, 0, 78)
+	buf = append(buf, version...)
+	buf = append(buf, k.depth)
+	buf = append(buf, k.parentFingerprint...)
+	buf = binary.BigEndian.AppendUint32(buf, k.childNumber)
+	buf = append(buf, k.chainCode...)
+
+	if k.privateKey != nil {
+		buf = append(buf, 0x00)
+		buf = append(buf, k.privateKey.d.Bytes()...)
+	} else {
+		_, sec := k.publicKey.SEC(true)
+		buf = append(buf, sec...)
+	}
+
+	return Base58Checksum(buf)
+}
+
+// ParseExtendedKey decodes an xprv, xpub, tprv or tpub string
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	payload, err := DecodeBase58Checksum(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) != 78 {
+		return nil, ErrBIP32KeyLength
+	}
+
+	version := payload[:4]
+	k := &ExtendedKey{
+		depth:             payload[4],
+		parentFingerprint: append([]byte{}, payload[5:9]...),
+		childNumber:       binary.BigEndian.Uint32(payload[9:13]),
+		chainCode:         append([]byte{}, payload[13:45]...),
+	}
+	keyData := payload[45:]
+
+	isPrivate := false
+	switch {
+	case bytes.Equal(version, bip32XprvVersion):
+		isPrivate = true
+	case bytes.Equal(version, bip32TprvVersion):
+		isPrivate, k.testnet = true, true
+	case bytes.Equal(version, bip32XpubVersion):
+	case bytes.Equal(version, bip32TpubVersion):
+		k.testnet = true
+	default:
+		return nil, ErrBIP32UnknownVersion
+	}
+
+	if k.depth == 0 && (k.childNumber != 0 || !bytes.Equal(k.parentFingerprint, make([]byte, 4))) {
+		return nil, ErrBIP32InvalidMasterInfo
+	}
+
+	if isPrivate {
+		if keyData[0] != 0x00 {
+			return nil, ErrBIP32InvalidKey
+		}
+
+		secret, err := Secp256k1().NewScalar(new(big.Int).SetBytes(keyData[1:]))
+		if err != nil || secret.IsZero() {
+			return nil, ErrBIP32InvalidKey
+		}
+
+		k.privateKey = newPrivateKey(secret.num)
+		k.publicKey = k.privateKey.Q
+		return k, nil
+	}
+
+	publicKey, err := ParseSEC(keyData)
+	if err != nil {
+		return nil, ErrBIP32InvalidKey
+	}
+
+	k.publicKey = publicKey
+
+	return k, nil
+}
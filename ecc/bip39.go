@@ -0,0 +1,112 @@
+package ecc
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+BIP39 mnemonic codes
+
+	checksum = first len(entropy) / 32 bits of sha256(entropy)
+	entropy || checksum is cut in 11 bits groups, every group is a word index
+
+so 128 - 256 bits of entropy give 12 - 24 words. The seed for NewMasterKey is
+	PBKDF2-HMAC-SHA512(password = mnemonic, salt = "mnemonic" || passphrase, 2048 rounds, 64 bytes)
+BIP39 asks for NFKD normalized mnemonic and passphrase, the English words are
+plain ASCII, a non ASCII passphrase has to be normalized by the caller.
+*/
+
+//go:embed wordlists/english.txt
+var englishWordlistText string
+
+var englishWordlist = sync.OnceValues(func() ([]string, map[string]int) {
+	words := strings.Fields(englishWordlistText)
+	indexes := make(map[string]int, len(words))
+	for i, word := range words {
+		indexes[word] = i
+	}
+	return words, indexes
+})
+
+var (
+	ErrEntropyLength    = errors.New("entropy must be 128 to 256 bits and a multiple of 32 bits")
+	ErrMnemonicLength   = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrMnemonicWord     = errors.New("mnemonic has a word that is not in the wordlist")
+	ErrMnemonicChecksum = errors.New("mnemonic checksum mismatch")
+)
+
+// NewMnemonic turns 16, 20, 24, 28 or 32 bytes of entropy into the mnemonic sentence
+func NewMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", ErrEntropyLength
+	}
+
+	words, _ := englishWordlist()
+	checksumBits := uint(len(entropy) / 4)
+	wordCount := (len(entropy)*8 + int(checksumBits)) / 11
+
+	hash := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, checksumBits)
+	bits.Or(bits, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	mnemonic := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	for i := wordCount - 1; i >= 0; i-- {
+		index := new(big.Int).And(bits, mask)
+		mnemonic[i] = words[index.Int64()]
+		bits.Rsh(bits, 11)
+	}
+
+	return strings.Join(mnemonic, " "), nil
+}
+
+// MnemonicToEntropy checks the words and the checksum and returns the entropy
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrMnemonicLength
+	}
+
+	_, indexes := englishWordlist()
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, ErrMnemonicWord
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(int64(1)<<checksumBits-1))
+	entropy := bits.Rsh(bits, checksumBits).FillBytes(make([]byte, int(checksumBits)*4))
+
+	hash := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(hash[0]>>(8-checksumBits)) {
+		return nil, ErrMnemonicChecksum
+	}
+
+	return entropy, nil
+}
+
+// ValidateMnemonic returns nil when every word is in the wordlist and the checksum matches
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed derives the 64 bytes seed, the mnemonic is not validated so
+// that seeds of other wordlists can still be derived, call ValidateMnemonic first
+func MnemonicToSeed(mnemonic string, passphrase string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
@@ -0,0 +1,212 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+/*
+Curve describes a short Weierstrass curve
+
+	y^2 = x^3 + ax + b over F_p
+
+with a generator point G of prime order n and cofactor h (the curve has h * n
+points). Points built from a Curve remember it, so Sign and Verify take n and G
+from the key instead of the secp256k1 constants.
+Only ECDSA (Sign, Verify, SEC, ScalarMul) works on every curve, the Bitcoin
+specific parts (Schnorr, taproot, MuSig2, recovery, BIP32) need secp256k1.
+*/
+type Curve struct {
+	name string
+	p    *big.Int
+	a    *big.Int
+	b    *big.Int
+	n    *big.Int
+	h    *big.Int
+	g    *Point
+}
+
+var (
+	ErrInvalidCurve     = errors.New("curve parameters are invalid")
+	ErrCurveGenerator   = errors.New("curve generator is not a point of order n on the curve")
+	ErrUnsupportedCurve = errors.New("operation is only defined on secp256k1")
+)
+
+// NewCurve checks the parameters and returns the curve, p and n must be prime,
+// the curve must not be singular (4a^3 + 27b^2 != 0) and n*G must be the identity
+func NewCurve(name string, p, a, b, gx, gy, n, h *big.Int) (*Curve, error) {
+	if p.Cmp(big.NewInt(3)) <= 0 || !p.ProbablyPrime(20) || !n.ProbablyPrime(20) || h.Sign() <= 0 {
+		return nil, ErrInvalidCurve
+	}
+
+	if a.Sign() < 0 || a.Cmp(p) >= 0 || b.Sign() < 0 || b.Cmp(p) >= 0 {
+		return nil, ErrInvalidCurve
+	}
+
+	aField := &FieldElement{order: p, num: a}
+	bField := &FieldElement{order: p, num: b}
+	discriminant := aField.Power(big.NewInt(3)).ScalarMul(big.NewInt(4)).add(bField.Power(big.NewInt(2)).ScalarMul(big.NewInt(27)))
+	if discriminant.num.Sign() == 0 {
+		return nil, ErrInvalidCurve
+	}
+
+	c := &Curve{name: name, p: p, a: a, b: b, n: n, h: h}
+
+	G, err := c.NewPoint(gx, gy)
+	if err != nil {
+		return nil, ErrCurveGenerator
+	}
+
+	if G.scalarMulJacobian(n).toAffine().x != nil {
+		return nil, ErrCurveGenerator
+	}
+	c.g = G
+
+	return c, nil
+}
+
+func (c *Curve) Name() string {
+	return c.name
+}
+
+func (c *Curve) P() *big.Int {
+	return new(big.Int).Set(c.p)
+}
+
+func (c *Curve) A() *big.Int {
+	return new(big.Int).Set(c.a)
+}
+
+func (c *Curve) B() *big.Int {
+	return new(big.Int).Set(c.b)
+}
+
+func (c *Curve) N() *big.Int {
+	return new(big.Int).Set(c.n)
+}
+
+func (c *Curve) H() *big.Int {
+	return new(big.Int).Set(c.h)
+}
+
+func (c *Curve) Generator() *Point {
+	return c.g
+}
+
+func (c *Curve) Identity() *Point {
+	return &Point{curve: c, a: c.aField(), b: c.bField()}
+}
+
+// NewPoint returns (x, y) on the curve, x and y must be less than p
+func (c *Curve) NewPoint(x *big.Int, y *big.Int) (*Point, error) {
+	if x.Sign() < 0 || x.Cmp(c.p) >= 0 || y.Sign() < 0 || y.Cmp(c.p) >= 0 {
+		return nil, ErrOutOfRange
+	}
+
+	point, err := NewEllipticCurvePoint(c.field(x), c.field(y), c.aField(), c.bField())
+	if err != nil {
+		return nil, err
+	}
+
+	point.curve = c
+	return point, nil
+}
+
+// NewPrivateKey returns the key with the given secret (1 to n - 1) on this curve
+func (c *Curve) NewPrivateKey(secret *big.Int) (*PrivateKey, error) {
+	if secret == nil || secret.Sign() <= 0 || secret.Cmp(c.n) >= 0 {
+		return nil, ErrPrivateKeyOutOfRange
+	}
+
+	return &PrivateKey{d: c.scalar(secret), Q: c.baseMul(secret)}, nil
+}
+
+// ScalarBaseMul computes k*G without leaking k through timing
+func (c *Curve) ScalarBaseMul(k *big.Int) *Point {
+	return c.baseMul(k)
+}
+
+// baseMul uses the precomputed table on secp256k1 and the ladder elsewhere
+func (c *Curve) baseMul(k *big.Int) *Point {
+	if c == Secp256k1() {
+		return baseMul(k)
+	}
+	return c.g.ScalarMulConstantTime(k)
+}
+
+func (c *Curve) field(num *big.Int) *FieldElement {
+	return &FieldElement{order: c.p, num: num}
+}
+
+func (c *Curve) aField() *FieldElement {
+	return c.field(c.a)
+}
+
+func (c *Curve) bField() *FieldElement {
+	return c.field(c.b)
+}
+
+// coordinateSize is the number of bytes of p, the size of x and y in SEC
+func (c *Curve) coordinateSize() int {
+	return (c.p.BitLen() + 7) / 8
+}
+
+func hexInt(s string) *big.Int {
+	num, _ := new(big.Int).SetString(s, 16)
+	return num
+}
+
+// secp256k1P is kept apart from the Curve so that S256Field does not need the curve
+var secp256k1P = sync.OnceValue(func() *big.Int {
+	return hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+})
+
+var secp256k1Curve = sync.OnceValue(func() *Curve {
+	c := &Curve{
+		name: "secp256k1",
+		p:    secp256k1P(),
+		a:    big.NewInt(0),
+		b:    big.NewInt(7),
+		n:    hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+		h:    big.NewInt(1),
+	}
+	// G is parsed once and shared, points are never modified in place
+	c.g = &Point{
+		curve: c,
+		a:     c.aField(),
+		b:     c.bField(),
+		x:     c.field(hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")),
+		y:     c.field(hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")),
+	}
+	return c
+})
+
+// Secp256k1 is the Bitcoin curve, y^2 = x^3 + 7
+func Secp256k1() *Curve {
+	return secp256k1Curve()
+}
+
+var p256Curve = sync.OnceValue(func() *Curve {
+	c := &Curve{
+		name: "P-256",
+		p:    hexInt("ffffffff00000001000000000000000000000000ffffffffffffffffffffffff"),
+		a:    hexInt("ffffffff00000001000000000000000000000000fffffffffffffffffffffffc"),
+		b:    hexInt("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b"),
+		n:    hexInt("ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551"),
+		h:    big.NewInt(1),
+	}
+	c.g = &Point{
+		curve: c,
+		a:     c.aField(),
+		b:     c.bField(),
+		x:     c.field(hexInt("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296")),
+		y:     c.field(hexInt("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5")),
+	}
+	return c
+})
+
+// P256 is NIST P-256 (secp256r1), y^2 = x^3 - 3x + b
+func P256() *Curve {
+	return p256Curve()
+}
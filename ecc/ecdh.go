@@ -0,0 +1,116 @@
+package ecc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+/*
+ECDH shared secret
+
+Both sides multiply their private key with the public key of the other one
+	d_A * Q_B = d_A * d_B * G = d_B * Q_A
+and hash the shared point, the raw point is never handed out.
+The default hash is the one of libsecp256k1 (secp256k1_ecdh with a nil hash
+function)
+	sha256((0x02 | parity of y) || x)
+the SHA-256 of the compressed SEC of the shared point, so secrets match the
+ones of Bitcoin Core and every library built on libsecp256k1.
+*/
+
+// ECDHHashFunc turns the shared point into the secret, x and y are big endian
+// and as long as p (32 bytes on secp256k1)
+type ECDHHashFunc func(x []byte, y []byte) []byte
+
+var (
+	ErrECDHNilPeerKey     = errors.New("ecdh peer public key is nil")
+	ErrECDHPeerCurve      = errors.New("ecdh peer public key is not on the curve of the private key")
+	ErrECDHPeerIdentity   = errors.New("ecdh peer public key is the identity point")
+	ErrECDHPeerSubgroup   = errors.New("ecdh peer public key is not in the subgroup of the generator")
+	ErrECDHSharedIdentity = errors.New("ecdh shared point is the identity point")
+	ErrECDHEmptySecret    = errors.New("ecdh hash function returned an empty secret")
+)
+
+// ECDH returns the libsecp256k1 compatible shared secret with peer, 32 bytes
+func (pk *PrivateKey) ECDH(peer *Point) ([]byte, error) {
+	return pk.ECDHWithHash(peer, nil)
+}
+
+// ECDHWithHash is ECDH with a custom hash of the shared point, a nil hash is
+// the default one (SHA-256 of the compressed point)
+func (pk *PrivateKey) ECDHWithHash(peer *Point, hash ECDHHashFunc) ([]byte, error) {
+	if err := pk.check(); err != nil {
+		return nil, err
+	}
+
+	curve := pk.Q.curve
+	Q, err := curve.checkPeerKey(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	// d is secret, the ladder keeps it out of the timing
+	S := Q.ScalarMulConstantTime(pk.d.num)
+	if S.x == nil {
+		return nil, ErrECDHSharedIdentity
+	}
+
+	size := curve.coordinateSize()
+	x := S.x.num.FillBytes(make([]byte, size))
+	y := S.y.num.FillBytes(make([]byte, size))
+
+	if hash == nil {
+		hash = ecdhHashSHA256
+	}
+
+	secret := hash(x, y)
+	if len(secret) == 0 {
+		return nil, ErrECDHEmptySecret
+	}
+
+	return secret, nil
+}
+
+func ecdhHashSHA256(x []byte, y []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x02 | y[len(y)-1]&1})
+	h.Write(x)
+	return h.Sum(nil)
+}
+
+/*
+checkPeerKey validates a public key received from someone else before our
+private key touches it. Points made by S256Point are not checked on creation,
+so the point is rebuilt with NewPoint (coordinates less than p and on the
+curve). On curves with a cofactor a point outside the subgroup of G would leak
+d mod h, it must satisfy n * Q = identity.
+*/
+func (c *Curve) checkPeerKey(peer *Point) (*Point, error) {
+	if peer == nil {
+		return nil, ErrECDHNilPeerKey
+	}
+
+	if peer.curve != c {
+		return nil, ErrECDHPeerCurve
+	}
+
+	if peer.x == nil && peer.y == nil {
+		return nil, ErrECDHPeerIdentity
+	}
+
+	if peer.x == nil || peer.y == nil || peer.x.num == nil || peer.y.num == nil {
+		return nil, ErrECDHPeerCurve
+	}
+
+	Q, err := c.NewPoint(peer.x.num, peer.y.num)
+	if err != nil {
+		return nil, ErrECDHPeerCurve
+	}
+
+	if c.h.Cmp(big.NewInt(1)) != 0 && Q.ScalarMul(c.n).x != nil {
+		return nil, ErrECDHPeerSubgroup
+	}
+
+	return Q, nil
+}
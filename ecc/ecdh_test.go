@@ -0,0 +1,91 @@
+package ecc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestECDHLibsecp256k1(t *testing.T) {
+	G := GeneratorPoint()
+
+	// libsecp256k1 checks secp256k1_ecdh(G, s) against sha256 of the compressed s*G,
+	// with s = 1 that is the hash of the compressed generator
+	secret, err := MustPrivateKey(big.NewInt(1)).ECDH(G)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("0f715baf5d4c2ed329785cef29e562f73488c8a2bb9dbc5700b361d54b9b0554")
+	if !bytes.Equal(secret, want) {
+		t.Errorf("ECDH(1, G) = %x, want %x", secret, want)
+	}
+
+	for _, s := range []int64{2, 3, 0xdeadbeef} {
+		_, compressed := G.ScalarMul(big.NewInt(s)).SEC(true)
+		want := sha256.Sum256(compressed)
+		if secret, _ := MustPrivateKey(big.NewInt(s)).ECDH(G); !bytes.Equal(secret, want[:]) {
+			t.Errorf("ECDH(%d, G) = %x, want %x", s, secret, want)
+		}
+	}
+}
+
+func TestECDHBothSides(t *testing.T) {
+	c := Secp256k1()
+	a := MustPrivateKey(hexInt("5f0ba0b0b9a1bbc1c0c7d8c1f0d1ae05d80c6d2f2b1a1e0e3c6a4b0f7d2e1c9a"))
+	b := MustPrivateKey(new(big.Int).Sub(c.n, big.NewInt(2)))
+
+	ab, err := a.ECDH(b.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := b.ECDH(a.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ab, ba) || len(ab) != 32 {
+		t.Errorf("a.ECDH(B) = %x, b.ECDH(A) = %x", ab, ba)
+	}
+
+	// a hash that keeps x gives the raw x coordinate of the shared point
+	raw := func(x []byte, y []byte) []byte { return x }
+	x, err := a.ECDHWithHash(b.Public(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := b.Public().ScalarMul(a.d.num)
+	if !bytes.Equal(x, shared.x.num.FillBytes(make([]byte, 32))) {
+		t.Errorf("raw x = %x, want %x", x, shared.x.num)
+	}
+	if other, _ := b.ECDHWithHash(a.Public(), raw); !bytes.Equal(x, other) {
+		t.Errorf("custom hash differs between both sides")
+	}
+
+	empty := func(x []byte, y []byte) []byte { return nil }
+	if _, err := a.ECDHWithHash(b.Public(), empty); err != ErrECDHEmptySecret {
+		t.Errorf("empty secret: %v, want ErrECDHEmptySecret", err)
+	}
+}
+
+func TestECDHInvalidPeer(t *testing.T) {
+	pk := MustPrivateKey(big.NewInt(12345))
+	G := GeneratorPoint()
+
+	peers := []struct {
+		name string
+		peer *Point
+		err  error
+	}{
+		{"nil", nil, ErrECDHNilPeerKey},
+		{"identity", Secp256k1().Identity(), ErrECDHPeerIdentity},
+		{"off curve", S256Point(G.x.num, new(big.Int).Add(G.y.num, big.NewInt(1))), ErrECDHPeerCurve},
+		{"x >= p", S256Point(new(big.Int).Add(G.x.num, secp256k1P()), G.y.num), ErrECDHPeerCurve},
+		{"other curve", P256().Generator(), ErrECDHPeerCurve},
+	}
+	for _, p := range peers {
+		if _, err := pk.ECDH(p.peer); err != p.err {
+			t.Errorf("%s: %v, want %v", p.name, err, p.err)
+		}
+	}
+}
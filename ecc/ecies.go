@@ -0,0 +1,323 @@
+package ecc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+/*
+ECIES, encryption to a secp256k1 public key
+
+The sender makes a fresh ephemeral key r, the ECDH of r with the recipient Q
+gives a symmetric key only r and d (Q = dG) can compute, R = rG goes in front
+of the ciphertext so the recipient can redo the ECDH with d.
+
+ECIESEncrypt writes the layout of eciespy / eciesjs (the common secp256k1
+ECIES of wallets)
+
+	R (65 bytes, uncompressed SEC) || nonce (16) || tag (16) || ciphertext
+	key = HKDF-SHA256(SEC(R) || SEC(rQ), both uncompressed, no salt, no info)
+	AES-256-GCM with the 16 bytes nonce
+
+ECIESEncryptElectrum writes the "BIE1" format of Electrum (encrypt_message),
+base64 encoded
+
+	"BIE1" || R (33 bytes, compressed SEC) || ciphertext || mac (32)
+	iv (16) || key_e (16) || key_m (32) = SHA-512(compressed SEC(rQ))
+	AES-128-CBC with PKCS#7 padding, mac = HMAC-SHA256(key_m, everything before it)
+
+Both are self-describing, ECIESDecrypt tells them apart by the first bytes:
+"BIE1" is Electrum, a SEC prefix (0x04, or 0x02 / 0x03 when the sender
+compressed R) is the GCM layout.
+*/
+
+const (
+	eciesNonceSize  = 16
+	eciesTagSize    = 16
+	electrumMagic   = "BIE1"
+	electrumMacSize = 32
+)
+
+var (
+	ErrECIESFormat      = errors.New("ecies ciphertext format is not recognized")
+	ErrECIESTooShort    = errors.New("ecies ciphertext is too short")
+	ErrECIESDecryption  = errors.New("ecies ciphertext failed authentication")
+	ErrECIESPadding     = errors.New("ecies plaintext padding is invalid")
+	ErrECIESEncoding    = errors.New("ecies ciphertext is not valid base64")
+	ErrECIESNotElectrum = errors.New("ecies ciphertext is not in the electrum format")
+)
+
+// ECIESEncrypt encrypts plaintext for the owner of this public key, see
+// PrivateKey.ECIESDecrypt
+func (p *Point) ECIESEncrypt(plaintext []byte) ([]byte, error) {
+	if p == nil || p.curve != Secp256k1() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	ephemeral, err := randomPrivateKey(p.curve)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, eciesNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return eciesEncrypt(p, plaintext, ephemeral, nonce)
+}
+
+// eciesEncrypt is ECIESEncrypt with the ephemeral key and the nonce given
+func eciesEncrypt(recipient *Point, plaintext []byte, ephemeral *PrivateKey, nonce []byte) ([]byte, error) {
+	_, R := ephemeral.Public().SEC(false)
+
+	key, err := ephemeral.ECDHWithHash(recipient, eciesHKDF(R))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := eciesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seal gives ciphertext || tag, the tag goes in front of the ciphertext
+	sealed := aead.Seal(nil, nonce, plaintext, nil)
+	ciphertext, tag := sealed[:len(plaintext)], sealed[len(plaintext):]
+
+	out := make([]byte, 0, len(R)+len(nonce)+len(sealed))
+	out = append(out, R...)
+	out = append(out, nonce...)
+	out = append(out, tag...)
+	return append(out, ciphertext...), nil
+}
+
+// ECIESEncryptElectrum encrypts plaintext the way Electrum's encrypt_message
+// does, the result is base64 like in Electrum
+func (p *Point) ECIESEncryptElectrum(plaintext []byte) (string, error) {
+	if p == nil || p.curve != Secp256k1() {
+		return "", ErrUnsupportedCurve
+	}
+
+	ephemeral, err := randomPrivateKey(p.curve)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := electrumEncrypt(p, plaintext, ephemeral)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func electrumEncrypt(recipient *Point, plaintext []byte, ephemeral *PrivateKey) ([]byte, error) {
+	key, err := ephemeral.ECDHWithHash(recipient, electrumKDF)
+	if err != nil {
+		return nil, err
+	}
+	iv, keyE, keyM := key[0:16], key[16:32], key[32:64]
+
+	block, err := aes.NewCipher(keyE)
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7, always at least one byte of padding
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+
+	_, R := ephemeral.Public().SEC(true)
+
+	encrypted := make([]byte, 0, len(electrumMagic)+len(R)+len(padded)+electrumMacSize)
+	encrypted = append(encrypted, electrumMagic...)
+	encrypted = append(encrypted, R...)
+	encrypted = append(encrypted, padded...)
+	return append(encrypted, electrumMAC(keyM, encrypted)...), nil
+}
+
+// ECIESDecrypt opens a ciphertext of ECIESEncrypt, or the raw (base64 decoded)
+// bytes of ECIESEncryptElectrum
+func (pk *PrivateKey) ECIESDecrypt(ciphertext []byte) ([]byte, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) == 0 {
+		return nil, ErrECIESTooShort
+	}
+
+	if bytes.HasPrefix(ciphertext, []byte(electrumMagic)) {
+		return pk.electrumDecrypt(ciphertext)
+	}
+
+	var rSize int
+	switch ciphertext[0] {
+	case 0x04:
+		rSize = 1 + 2*pk.Q.curve.coordinateSize()
+	case 0x02, 0x03:
+		rSize = 1 + pk.Q.curve.coordinateSize()
+	default:
+		return nil, ErrECIESFormat
+	}
+
+	if len(ciphertext) < rSize+eciesNonceSize+eciesTagSize {
+		return nil, ErrECIESTooShort
+	}
+
+	R, err := ParseSEC(ciphertext[:rSize])
+	if err != nil {
+		return nil, err
+	}
+
+	// the key is derived from the uncompressed R, even when it was sent compressed
+	_, uncompressedR := R.SEC(false)
+	key, err := pk.ECDHWithHash(R, eciesHKDF(uncompressedR))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := eciesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := ciphertext[rSize:]
+	nonce := rest[:eciesNonceSize]
+	tag := rest[eciesNonceSize : eciesNonceSize+eciesTagSize]
+	sealed := append(append([]byte{}, rest[eciesNonceSize+eciesTagSize:]...), tag...)
+
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrECIESDecryption
+	}
+
+	return plaintext, nil
+}
+
+// ECIESDecryptElectrum opens the base64 output of Electrum's encrypt_message
+// (and of ECIESEncryptElectrum)
+func (pk *PrivateKey) ECIESDecryptElectrum(encoded string) ([]byte, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrECIESEncoding
+	}
+
+	if !bytes.HasPrefix(encrypted, []byte(electrumMagic)) {
+		return nil, ErrECIESNotElectrum
+	}
+
+	return pk.electrumDecrypt(encrypted)
+}
+
+func (pk *PrivateKey) electrumDecrypt(encrypted []byte) ([]byte, error) {
+	// magic, R, one AES block and the mac (85 bytes)
+	if len(encrypted) < len(electrumMagic)+33+aes.BlockSize+electrumMacSize {
+		return nil, ErrECIESTooShort
+	}
+
+	body, mac := encrypted[:len(encrypted)-electrumMacSize], encrypted[len(encrypted)-electrumMacSize:]
+	padded := append([]byte{}, body[len(electrumMagic)+33:]...)
+	if len(padded)%aes.BlockSize != 0 {
+		return nil, ErrECIESFormat
+	}
+
+	R, err := ParseSEC(body[len(electrumMagic) : len(electrumMagic)+33])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pk.ECDHWithHash(R, electrumKDF)
+	if err != nil {
+		return nil, err
+	}
+	iv, keyE, keyM := key[0:16], key[16:32], key[32:64]
+
+	// the mac is checked before anything is decrypted
+	if !hmac.Equal(mac, electrumMAC(keyM, body)) {
+		return nil, ErrECIESDecryption
+	}
+
+	block, err := aes.NewCipher(keyE)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(padded, padded)
+
+	padding := int(padded[len(padded)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrECIESPadding
+	}
+	for _, b := range padded[len(padded)-padding:] {
+		if int(b) != padding {
+			return nil, ErrECIESPadding
+		}
+	}
+
+	return padded[:len(padded)-padding], nil
+}
+
+// eciesHKDF derives the AES-256 key from R and the uncompressed shared point
+func eciesHKDF(R []byte) ECDHHashFunc {
+	return func(x []byte, y []byte) []byte {
+		master := make([]byte, 0, len(R)+1+len(x)+len(y))
+		master = append(master, R...)
+		master = append(master, 0x04)
+		master = append(master, x...)
+		master = append(master, y...)
+
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, master, nil, nil), key); err != nil {
+			return nil
+		}
+		return key
+	}
+}
+
+func eciesGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, eciesNonceSize)
+}
+
+// electrumKDF is SHA-512 of the compressed shared point
+func electrumKDF(x []byte, y []byte) []byte {
+	h := sha512.New()
+	h.Write([]byte{0x02 | y[len(y)-1]&1})
+	h.Write(x)
+	return h.Sum(nil)
+}
+
+func electrumMAC(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// randomPrivateKey draws a key uniformly in 1 to n - 1
+func randomPrivateKey(c *Curve) (*PrivateKey, error) {
+	secret, err := rand.Int(rand.Reader, new(big.Int).Sub(c.n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return c.NewPrivateKey(secret.Add(secret, big.NewInt(1)))
+}
@@ -0,0 +1,233 @@
+package ecc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+/*
+Known answers for both layouts, with fixed recipient, ephemeral key and nonce.
+They were made with a separate implementation of the eciespy and Electrum
+algorithms (btcec and the Go standard library, nothing from this package).
+*/
+var (
+	eciesRecipient = "5f2c0e9c1d6b3a4e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e"
+	eciesEphemeral = "0ae5f3d9c7b1a2938475665748392a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f"
+	eciesNonce     = "000102030405060708090a0b0c0d0e0f"
+)
+
+var eciesVectors = []struct {
+	plaintext string
+	gcm       string
+	electrum  string
+}{
+	{
+		plaintext: "me<(s_s)>age",
+		gcm:       "047fe0312666bb84a062500d959e310911706f412b5f716768db99a5751a42b9ddceabef31a5ed39b0629f6bf9b1885057fab3386feb0a45b6fa72f9ada01e2ce9000102030405060708090a0b0c0d0e0f3cc7125ee74b4cf5c25d77da5b1371f0af4c8914675eb36a9be08c5a",
+		electrum:  "QklFMQN/4DEmZruEoGJQDZWeMQkRcG9BK19xZ2jbmaV1GkK53WBQ8lnzopuL/0gT1aGXGOdjuhOsXHifcMJi70S2aDTD9oqziqUMK5MZU/Pvi+E32g==",
+	},
+	{
+		plaintext: "",
+		gcm:       "047fe0312666bb84a062500d959e310911706f412b5f716768db99a5751a42b9ddceabef31a5ed39b0629f6bf9b1885057fab3386feb0a45b6fa72f9ada01e2ce9000102030405060708090a0b0c0d0e0f0b6fe8ac3745f3d1d656ce3073df7baf",
+		electrum:  "QklFMQN/4DEmZruEoGJQDZWeMQkRcG9BK19xZ2jbmaV1GkK53UOa/5ZpVJkb4B6P0XJu/hFWMGGz6Kn3TBP4RCNWCnIwxcil+Y90PyJ0J6pzB6VhQw==",
+	},
+	// 32 bytes, PKCS#7 adds a whole block
+	{
+		plaintext: "helloworld, sixteen bytes ok!!!!",
+		gcm:       "047fe0312666bb84a062500d959e310911706f412b5f716768db99a5751a42b9ddceabef31a5ed39b0629f6bf9b1885057fab3386feb0a45b6fa72f9ada01e2ce9000102030405060708090a0b0c0d0e0fdf8c53d7538cfe8fe33eed2ae598c268aa4cd9507b76af31c9e5c71f345b8a20229b57d747063dd470de6e143f894544",
+		electrum:  "QklFMQN/4DEmZruEoGJQDZWeMQkRcG9BK19xZ2jbmaV1GkK53f+FnLw9M5xY8ICzE9mBhaOGvdlaTy0nIOhszU28KIvj8sVGWe7YTY02Xaxg7aoaoEbVzmtcyiWrJOQV6VJ5orOWFo6msyU7zl5LBEbr1j9W",
+	},
+}
+
+func TestECIESKnownAnswers(t *testing.T) {
+	pk := MustPrivateKey(hexInt(eciesRecipient))
+	ephemeral := MustPrivateKey(hexInt(eciesEphemeral))
+	nonce, _ := hex.DecodeString(eciesNonce)
+
+	for _, v := range eciesVectors {
+		gcm, _ := hex.DecodeString(v.gcm)
+
+		plaintext, err := pk.ECIESDecrypt(gcm)
+		if err != nil || string(plaintext) != v.plaintext {
+			t.Errorf("%q: ECIESDecrypt = %q, %v", v.plaintext, plaintext, err)
+		}
+		if got, _ := eciesEncrypt(pk.Public(), []byte(v.plaintext), ephemeral, nonce); !bytes.Equal(got, gcm) {
+			t.Errorf("%q: eciesEncrypt = %x, want %s", v.plaintext, got, v.gcm)
+		}
+
+		plaintext, err = pk.ECIESDecryptElectrum(v.electrum)
+		if err != nil || string(plaintext) != v.plaintext {
+			t.Errorf("%q: ECIESDecryptElectrum = %q, %v", v.plaintext, plaintext, err)
+		}
+		got, _ := electrumEncrypt(pk.Public(), []byte(v.plaintext), ephemeral)
+		if encoded := base64.StdEncoding.EncodeToString(got); encoded != v.electrum {
+			t.Errorf("%q: electrumEncrypt = %s, want %s", v.plaintext, encoded, v.electrum)
+		}
+
+		// ECIESDecrypt also takes the raw Electrum bytes
+		raw, _ := base64.StdEncoding.DecodeString(v.electrum)
+		if plaintext, err := pk.ECIESDecrypt(raw); err != nil || string(plaintext) != v.plaintext {
+			t.Errorf("%q: ECIESDecrypt of the Electrum bytes = %q, %v", v.plaintext, plaintext, err)
+		}
+	}
+}
+
+func TestECIESRoundTrip(t *testing.T) {
+	pk := MustPrivateKey(hexInt("c0ffee0000000000000000000000000000000000000000000000000000000001"))
+
+	for _, size := range []int{0, 1, 15, 16, 17, 100, 1000} {
+		msg := bytes.Repeat([]byte{byte(size)}, size)
+
+		ciphertext, err := pk.Public().ECIESEncrypt(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != 65+eciesNonceSize+eciesTagSize+size {
+			t.Errorf("%d bytes: ciphertext is %d bytes long", size, len(ciphertext))
+		}
+		if plaintext, err := pk.ECIESDecrypt(ciphertext); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("%d bytes: GCM round trip gives %x, %v", size, plaintext, err)
+		}
+
+		// eciespy can also send R compressed
+		R, err := ParseSEC(ciphertext[:65])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, compressedR := R.SEC(true)
+		compressed := append(compressedR, ciphertext[65:]...)
+		if plaintext, err := pk.ECIESDecrypt(compressed); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("%d bytes: GCM round trip with a compressed R gives %x, %v", size, plaintext, err)
+		}
+
+		encoded, err := pk.Public().ECIESEncryptElectrum(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext, err := pk.ECIESDecryptElectrum(encoded); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Errorf("%d bytes: Electrum round trip gives %x, %v", size, plaintext, err)
+		}
+	}
+}
+
+// electrumWithPadding encrypts padded as it is, without adding PKCS#7 padding,
+// and macs it like Electrum so the padding is the only thing wrong
+func electrumWithPadding(t *testing.T, recipient *Point, ephemeral *PrivateKey, padded []byte) []byte {
+	t.Helper()
+	key, err := ephemeral.ECDHWithHash(recipient, electrumKDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := aes.NewCipher(key[16:32])
+	encrypted := append([]byte{}, padded...)
+	cipher.NewCBCEncrypter(block, key[0:16]).CryptBlocks(encrypted, encrypted)
+
+	_, R := ephemeral.Public().SEC(true)
+	body := append(append([]byte(electrumMagic), R...), encrypted...)
+	return append(body, electrumMAC(key[32:64], body)...)
+}
+
+func TestECIESDecryptErrors(t *testing.T) {
+	pk := MustPrivateKey(hexInt(eciesRecipient))
+	ephemeral := MustPrivateKey(hexInt(eciesEphemeral))
+	gcm, _ := hex.DecodeString(eciesVectors[0].gcm)
+	electrum, _ := base64.StdEncoding.DecodeString(eciesVectors[0].electrum)
+
+	flip := func(data []byte, i int) []byte {
+		flipped := append([]byte{}, data...)
+		flipped[i] ^= 0x01
+		return flipped
+	}
+
+	// 5 is not the x of a point on secp256k1
+	notOnCurve := append([]byte{0x02}, make([]byte, 32)...)
+	notOnCurve[32] = 5
+
+	block := func(last ...byte) []byte {
+		padded := bytes.Repeat([]byte("a"), aes.BlockSize-len(last))
+		return append(padded, last...)
+	}
+
+	cases := []struct {
+		name       string
+		ciphertext []byte
+		want       error
+	}{
+		{"GCM tag", flip(gcm, 65+eciesNonceSize), ErrECIESDecryption},
+		{"GCM ciphertext", flip(gcm, len(gcm)-1), ErrECIESDecryption},
+		{"GCM nonce", flip(gcm, 65), ErrECIESDecryption},
+		{"GCM R", flip(gcm, 64), ErrSECNotOnCurve},
+		{"GCM compressed R off the curve", append(notOnCurve, gcm[65:]...), ErrSECNoSquareRoot},
+		{"GCM truncated to R, nonce and half a tag", gcm[:65+eciesNonceSize+8], ErrECIESTooShort},
+		{"GCM truncated to a compressed R", gcm[:33], ErrECIESTooShort},
+		{"GCM truncated by one byte", gcm[:len(gcm)-1], ErrECIESDecryption},
+		{"Electrum mac", flip(electrum, len(electrum)-1), ErrECIESDecryption},
+		{"Electrum ciphertext", flip(electrum, len(electrumMagic)+33), ErrECIESDecryption},
+		{"Electrum R", append(append([]byte(electrumMagic), notOnCurve...), electrum[37:]...), ErrSECNoSquareRoot},
+		{"Electrum truncated below one block", electrum[:len(electrum)-electrumMacSize-1], ErrECIESTooShort},
+		{"Electrum not a whole block", append(append([]byte{}, electrum[:len(electrum)-electrumMacSize]...), make([]byte, electrumMacSize+1)...), ErrECIESFormat},
+		{"Electrum padding 0", electrumWithPadding(t, pk.Public(), ephemeral, block(0)), ErrECIESPadding},
+		{"Electrum padding 17", electrumWithPadding(t, pk.Public(), ephemeral, block(17)), ErrECIESPadding},
+		{"Electrum padding bytes differ", electrumWithPadding(t, pk.Public(), ephemeral, block(2, 3, 3)), ErrECIESPadding},
+		{"empty", nil, ErrECIESTooShort},
+		{"prefix 0x00", append([]byte{0x00}, gcm[1:]...), ErrECIESFormat},
+		{"prefix 0x05", append([]byte{0x05}, gcm[1:]...), ErrECIESFormat},
+		{"hybrid prefix 0x06", append([]byte{0x06}, gcm[1:]...), ErrECIESFormat},
+		{"magic BIE2", append([]byte("BIE2"), electrum[4:]...), ErrECIESFormat},
+	}
+
+	for _, c := range cases {
+		if _, err := pk.ECIESDecrypt(c.ciphertext); !errors.Is(err, c.want) {
+			t.Errorf("%s: %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	// a padding that is right is accepted by the same construction
+	if plaintext, err := pk.ECIESDecrypt(electrumWithPadding(t, pk.Public(), ephemeral, block(3, 3, 3))); err != nil || len(plaintext) != aes.BlockSize-3 {
+		t.Errorf("valid padding: %q, %v", plaintext, err)
+	}
+
+	// somebody else's key fails to authenticate both layouts
+	other := MustPrivateKey(big.NewInt(2))
+	if _, err := other.ECIESDecrypt(gcm); err != ErrECIESDecryption {
+		t.Errorf("GCM with another key: %v, want ErrECIESDecryption", err)
+	}
+	if _, err := other.ECIESDecryptElectrum(eciesVectors[0].electrum); err != ErrECIESDecryption {
+		t.Errorf("Electrum with another key: %v, want ErrECIESDecryption", err)
+	}
+
+	if _, err := pk.ECIESDecryptElectrum("QklFMQ*"); err != ErrECIESEncoding {
+		t.Errorf("bad base64: %v, want ErrECIESEncoding", err)
+	}
+	if _, err := pk.ECIESDecryptElectrum(base64.StdEncoding.EncodeToString(gcm)); err != ErrECIESNotElectrum {
+		t.Errorf("GCM layout given to ECIESDecryptElectrum: %v, want ErrECIESNotElectrum", err)
+	}
+}
+
+func TestECIESInvalidKeys(t *testing.T) {
+	if _, err := P256().Generator().ECIESEncrypt(nil); err != ErrUnsupportedCurve {
+		t.Errorf("P-256 ECIESEncrypt: %v, want ErrUnsupportedCurve", err)
+	}
+	if _, err := P256().Generator().ECIESEncryptElectrum(nil); err != ErrUnsupportedCurve {
+		t.Errorf("P-256 ECIESEncryptElectrum: %v, want ErrUnsupportedCurve", err)
+	}
+	if _, err := Secp256k1().Identity().ECIESEncrypt(nil); err != ErrECDHPeerIdentity {
+		t.Errorf("identity ECIESEncrypt: %v, want ErrECDHPeerIdentity", err)
+	}
+
+	gcm, _ := hex.DecodeString(eciesVectors[0].gcm)
+	if _, err := (&PrivateKey{}).ECIESDecrypt(gcm); err != ErrPrivateKeyOutOfRange {
+		t.Errorf("zero value PrivateKey: %v, want ErrPrivateKeyOutOfRange", err)
+	}
+	p256Key, _ := P256().NewPrivateKey(big.NewInt(1))
+	if _, err := p256Key.ECIESDecrypt(gcm); err != ErrUnsupportedCurve {
+		t.Errorf("P-256 private key: %v, want ErrUnsupportedCurve", err)
+	}
+}
@@ -1,6 +1,7 @@
 package ecc
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 )
@@ -26,82 +27,106 @@ type FieldElement struct {
 }
 
 func S256Field(num *big.Int) *FieldElement {
-	twoExp256 := new(big.Int).Exp(big.NewInt(2), big.NewInt(256), nil)
-	twoExp32 := new(big.Int).Exp(big.NewInt(2), big.NewInt(32), nil)
-	p := new(big.Int).Sub(new(big.Int).Sub(twoExp256, twoExp32), big.NewInt(977))
-	return &FieldElement{p, num}
+	return &FieldElement{secp256k1P(), num}
 }
 
-func NewFieldElement(order *big.Int, num *big.Int) *FieldElement {
-	if order.Cmp(num) == -1 {
-		err := fmt.Sprintf("Num not in the range of 0 to %v", order)
+// Errors returned by the field and curve operations
+var (
+	ErrOutOfRange      = errors.New("number is not in the range of 0 to order - 1")
+	ErrMismatchedField = errors.New("field elements are not in the same field")
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrNoSquareRoot    = errors.New("square root needs an order with order + 1 divisible by 4")
+)
+
+func NewFieldElement(order *big.Int, num *big.Int) (*FieldElement, error) {
+	if num.Sign() < 0 || num.Cmp(order) >= 0 {
+		return nil, ErrOutOfRange
+	}
+	return &FieldElement{order, num}, nil
+}
+
+// MustFieldElement is NewFieldElement that panics when num is out of range
+func MustFieldElement(order *big.Int, num *big.Int) *FieldElement {
+	fe, err := NewFieldElement(order, num)
+	if err != nil {
 		panic(err)
 	}
-	return &FieldElement{order, num}
+	return fe
 }
 
 // PUBLIC METHODS
 
-func (fe *FieldElement) Sqrt() *FieldElement {
+func (fe *FieldElement) Sqrt() (*FieldElement, error) {
 	// make sure (p + 1) % 4 == 0
 	orderAddOne := new(big.Int).Add(fe.order, big.NewInt(1))
 	modRes := new(big.Int).Mod(orderAddOne, big.NewInt(4))
 
 	if modRes.Cmp(big.NewInt(0)) != 0 {
-		panic("Order plus one mod 4 is not 0")
+		return nil, ErrNoSquareRoot
 	}
 
-	return fe.Power(new(big.Int).Div(orderAddOne, big.NewInt(4)))
+	return fe.sqrt(), nil
 }
 
-func (fe *FieldElement) Divide(other *FieldElement) *FieldElement {
-	fe.checkOrder(other)
+func (fe *FieldElement) Divide(other *FieldElement) (*FieldElement, error) {
+	if err := fe.checkOrder(other); err != nil {
+		return nil, err
+	}
 
-	op := new(big.Int)
-	otherReverse := other.Power(op.Sub(fe.order, big.NewInt(int64(2))))
+	if other.num.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
 
-	return fe.Multiply(otherReverse)
+	return fe.mul(other.inv()), nil
 }
 
-func (fe *FieldElement) Inverse() *FieldElement {
-	return fe.Power(new(big.Int).Sub(fe.order, big.NewInt(int64(2))))
+func (fe *FieldElement) Inverse() (*FieldElement, error) {
+	if fe.num.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+
+	return fe.inv(), nil
 }
 
 func (fe *FieldElement) ScalarMul(val *big.Int) *FieldElement {
 	var op big.Int
-	return NewFieldElement(fe.order, op.Mod(op.Mul(fe.num, val), fe.order))
+	return &FieldElement{fe.order, op.Mod(op.Mul(fe.num, val), fe.order)}
 }
 
 func (fe *FieldElement) Power(power *big.Int) *FieldElement {
 	var op big.Int
 	t := op.Mod(power, op.Sub(fe.order, big.NewInt(int64(1))))
 	res := op.Exp(fe.num, t, fe.order)
-	return NewFieldElement(fe.order, res)
+	return &FieldElement{fe.order, res}
 }
 
-func (fe *FieldElement) Multiply(other *FieldElement) *FieldElement {
-	fe.checkOrder(other)
-	var op big.Int
+func (fe *FieldElement) Multiply(other *FieldElement) (*FieldElement, error) {
+	if err := fe.checkOrder(other); err != nil {
+		return nil, err
+	}
 
-	return NewFieldElement(
-		fe.order,
-		op.Mod(op.Mul(fe.num, other.num), fe.order),
-	)
+	return fe.mul(other), nil
 }
 
-func (fe *FieldElement) Substract(other *FieldElement) *FieldElement {
-	return fe.Add(other.Negate())
+func (fe *FieldElement) Substract(other *FieldElement) (*FieldElement, error) {
+	if err := fe.checkOrder(other); err != nil {
+		return nil, err
+	}
+
+	return fe.sub(other), nil
 }
 
-func (fe *FieldElement) Add(other *FieldElement) *FieldElement {
-	fe.checkOrder(other)
-	var op big.Int
-	return NewFieldElement(fe.order, op.Mod(op.Add(fe.num, other.num), fe.order))
+func (fe *FieldElement) Add(other *FieldElement) (*FieldElement, error) {
+	if err := fe.checkOrder(other); err != nil {
+		return nil, err
+	}
+
+	return fe.add(other), nil
 }
 
 func (fe *FieldElement) Negate() *FieldElement {
 	var op big.Int
-	return NewFieldElement(fe.order, op.Sub(fe.order, fe.num))
+	return &FieldElement{fe.order, op.Mod(op.Sub(fe.order, fe.num), fe.order)}
 }
 
 func (fe *FieldElement) String() string {
@@ -109,13 +134,44 @@ func (fe *FieldElement) String() string {
 }
 
 func (fe *FieldElement) EqualTo(other *FieldElement) bool {
-	return fe.num.Cmp(other.num) == 0 && other.order.Cmp(other.order) == 0
+	return fe.num.Cmp(other.num) == 0 && fe.order.Cmp(other.order) == 0
 }
 
 // PRIVATE METHODS
 
-func (fe *FieldElement) checkOrder(other *FieldElement) {
+func (fe *FieldElement) checkOrder(other *FieldElement) error {
 	if fe.order.Cmp(other.order) != 0 {
-		panic("Order are not equal!")
+		return ErrMismatchedField
 	}
+	return nil
+}
+
+/*
+The unchecked versions below are used inside the package where both operands
+come from the same curve, they skip checkOrder and the zero checks.
+*/
+
+func (fe *FieldElement) add(other *FieldElement) *FieldElement {
+	var op big.Int
+	return &FieldElement{fe.order, op.Mod(op.Add(fe.num, other.num), fe.order)}
+}
+
+func (fe *FieldElement) sub(other *FieldElement) *FieldElement {
+	var op big.Int
+	return &FieldElement{fe.order, op.Mod(op.Sub(fe.num, other.num), fe.order)}
+}
+
+func (fe *FieldElement) mul(other *FieldElement) *FieldElement {
+	var op big.Int
+	return &FieldElement{fe.order, op.Mod(op.Mul(fe.num, other.num), fe.order)}
+}
+
+// inv is fe^(p - 2), zero gives zero
+func (fe *FieldElement) inv() *FieldElement {
+	return fe.Power(new(big.Int).Sub(fe.order, big.NewInt(int64(2))))
+}
+
+// sqrt is fe^((p + 1) / 4), only valid when p % 4 == 3
+func (fe *FieldElement) sqrt() *FieldElement {
+	return fe.Power(new(big.Int).Div(new(big.Int).Add(fe.order, big.NewInt(1)), big.NewInt(4)))
 }
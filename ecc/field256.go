@@ -0,0 +1,213 @@
+package ecc
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+/*
+s256Element is an element of the secp256k1 field in 4 little endian 64-bit
+limbs, always fully reduced (less than p).
+
+FieldElement goes through math/big, every Add or Multiply allocates a new
+big.Int and Mod does a generic division. Here the limbs are plain values on
+the stack and the reduction uses the shape of p:
+
+	p = 2^256 - 2^32 - 977  =>  2^256 = 2^32 + 977 (mod p)
+
+so a 512 bits product hi * 2^256 + lo is reduced to lo + hi * (2^32 + 977),
+the few bits that are still above 2^256 are folded the same way once more and
+one conditional subtraction of p finishes it. No branch depends on the values.
+Points on secp256k1 run their Jacobian arithmetic on it, see jacobian256.go.
+*/
+type s256Element [4]uint64
+
+// 2^256 mod p
+const s256ReductionConst = 0x1000003d1
+
+var s256Prime = s256Element{0xfffffffefffffc2f, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff}
+
+var s256One = s256Element{1, 0, 0, 0}
+
+// s256ElementFromBytes reads 32 big endian bytes, the value must be less than p
+func s256ElementFromBytes(buf []byte) s256Element {
+	return s256Element{
+		binary.BigEndian.Uint64(buf[24:32]),
+		binary.BigEndian.Uint64(buf[16:24]),
+		binary.BigEndian.Uint64(buf[8:16]),
+		binary.BigEndian.Uint64(buf[0:8]),
+	}
+}
+
+func s256ElementFromBig(num *big.Int) s256Element {
+	return s256ElementFromBytes(num.FillBytes(make([]byte, 32)))
+}
+
+func (a s256Element) bytes() []byte {
+	buf := make([]byte, 32)
+	binary.BigEndian.PutUint64(buf[0:8], a[3])
+	binary.BigEndian.PutUint64(buf[8:16], a[2])
+	binary.BigEndian.PutUint64(buf[16:24], a[1])
+	binary.BigEndian.PutUint64(buf[24:32], a[0])
+	return buf
+}
+
+func (a s256Element) fieldElement() *FieldElement {
+	return &FieldElement{order: secp256k1P(), num: new(big.Int).SetBytes(a.bytes())}
+}
+
+// isZero returns 1 when a is 0, otherwise 0
+func (a s256Element) isZero() uint64 {
+	x := a[0] | a[1] | a[2] | a[3]
+	return 1 ^ (x|-x)>>63
+}
+
+// equal returns 1 when a = b, otherwise 0
+func (a s256Element) equal(b s256Element) uint64 {
+	return s256Element{a[0] ^ b[0], a[1] ^ b[1], a[2] ^ b[2], a[3] ^ b[3]}.isZero()
+}
+
+// s256Select returns a when cond is 1 and b when cond is 0
+func s256Select(cond uint64, a s256Element, b s256Element) s256Element {
+	mask := -cond
+	return s256Element{
+		b[0] ^ mask&(a[0]^b[0]),
+		b[1] ^ mask&(a[1]^b[1]),
+		b[2] ^ mask&(a[2]^b[2]),
+		b[3] ^ mask&(a[3]^b[3]),
+	}
+}
+
+func (a s256Element) add(b s256Element) s256Element {
+	var sum s256Element
+	var carry uint64
+	sum[0], carry = bits.Add64(a[0], b[0], 0)
+	sum[1], carry = bits.Add64(a[1], b[1], carry)
+	sum[2], carry = bits.Add64(a[2], b[2], carry)
+	sum[3], carry = bits.Add64(a[3], b[3], carry)
+	return sum.reduceOnce(carry)
+}
+
+// reduceOnce subtracts p from carry * 2^256 + a when that is at least p, the
+// value has to be less than 2p
+func (a s256Element) reduceOnce(carry uint64) s256Element {
+	var diff s256Element
+	var borrow uint64
+	diff[0], borrow = bits.Sub64(a[0], s256Prime[0], 0)
+	diff[1], borrow = bits.Sub64(a[1], s256Prime[1], borrow)
+	diff[2], borrow = bits.Sub64(a[2], s256Prime[2], borrow)
+	diff[3], borrow = bits.Sub64(a[3], s256Prime[3], borrow)
+
+	// a is already reduced when there is no carry and a - p borrows
+	return s256Select((1^carry)&borrow, a, diff)
+}
+
+func (a s256Element) sub(b s256Element) s256Element {
+	var diff s256Element
+	var borrow uint64
+	diff[0], borrow = bits.Sub64(a[0], b[0], 0)
+	diff[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	diff[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	diff[3], borrow = bits.Sub64(a[3], b[3], borrow)
+
+	// a < b => add p back
+	mask := -borrow
+	var carry uint64
+	diff[0], carry = bits.Add64(diff[0], s256Prime[0]&mask, 0)
+	diff[1], carry = bits.Add64(diff[1], s256Prime[1]&mask, carry)
+	diff[2], carry = bits.Add64(diff[2], s256Prime[2]&mask, carry)
+	diff[3], _ = bits.Add64(diff[3], s256Prime[3]&mask, carry)
+	return diff
+}
+
+func (a s256Element) neg() s256Element {
+	return s256Element{}.sub(a)
+}
+
+func (a s256Element) mul(b s256Element) s256Element {
+	var product [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			var c uint64
+			lo, c = bits.Add64(lo, product[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			product[i+j] = lo
+			carry = hi
+		}
+		product[i+4] = carry
+	}
+	return s256Reduce(product)
+}
+
+func (a s256Element) square() s256Element {
+	return a.mul(a)
+}
+
+// squareN squares a n times, a^(2^n)
+func (a s256Element) squareN(n int) s256Element {
+	for i := 0; i < n; i++ {
+		a = a.mul(a)
+	}
+	return a
+}
+
+// s256Reduce reduces the 512 bits product (little endian limbs) mod p
+func s256Reduce(product [8]uint64) s256Element {
+	// lo + hi * (2^32 + 977), 5 limbs
+	var t s256Element
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		hi, lo := bits.Mul64(product[i+4], s256ReductionConst)
+		var c uint64
+		lo, c = bits.Add64(lo, product[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, carry, 0)
+		hi += c
+		t[i] = lo
+		carry = hi
+	}
+
+	// the fifth limb is below 2^34, fold it again
+	hi, lo := bits.Mul64(carry, s256ReductionConst)
+	var c uint64
+	t[0], c = bits.Add64(t[0], lo, 0)
+	t[1], c = bits.Add64(t[1], hi, c)
+	t[2], c = bits.Add64(t[2], 0, c)
+	t[3], c = bits.Add64(t[3], 0, c)
+
+	// when that carries t is small, the last fold can't carry
+	t[0], c = bits.Add64(t[0], c*s256ReductionConst, 0)
+	t[1], c = bits.Add64(t[1], 0, c)
+	t[2], c = bits.Add64(t[2], 0, c)
+	t[3], _ = bits.Add64(t[3], 0, c)
+
+	return t.reduceOnce(0)
+}
+
+/*
+inverse is a^(p - 2) with the addition chain of libsecp256k1, xN stands for
+a^(2^N - 1) (N one bits), 255 squarings and 15 multiplications
+*/
+func (a s256Element) inverse() s256Element {
+	x2 := a.square().mul(a)
+	x3 := x2.square().mul(a)
+	x6 := x3.squareN(3).mul(x3)
+	x9 := x6.squareN(3).mul(x3)
+	x11 := x9.squareN(2).mul(x2)
+	x22 := x11.squareN(11).mul(x11)
+	x44 := x22.squareN(22).mul(x22)
+	x88 := x44.squareN(44).mul(x44)
+	x176 := x88.squareN(88).mul(x88)
+	x220 := x176.squareN(44).mul(x44)
+	x223 := x220.squareN(3).mul(x3)
+
+	t := x223.squareN(23).mul(x22)
+	t = t.squareN(5).mul(a)
+	t = t.squareN(3).mul(x2)
+	return t.squareN(2).mul(a)
+}
@@ -0,0 +1,196 @@
+package ecc
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func s256Big(a s256Element) *big.Int {
+	return new(big.Int).SetBytes(a.bytes())
+}
+
+// s256EdgeValues are the values where the carries and the reduction go
+// through every limb
+func s256EdgeValues() []*big.Int {
+	p := secp256k1P()
+	one := big.NewInt(1)
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(977),
+		new(big.Int).Sub(p, one),
+		new(big.Int).Sub(p, big.NewInt(2)),
+		new(big.Int).Rsh(p, 1),
+		new(big.Int).Lsh(one, 255),
+		new(big.Int).SetUint64(s256ReductionConst),
+	}
+
+	// 2^64k - 1 and 2^64k carry from one limb into the next
+	for k := uint(1); k < 4; k++ {
+		limb := new(big.Int).Lsh(one, 64*k)
+		values = append(values, limb, new(big.Int).Sub(limb, one))
+	}
+
+	return values
+}
+
+func s256RandomValues(rng *rand.Rand, count int) []*big.Int {
+	values := make([]*big.Int, count)
+	for i := range values {
+		values[i] = new(big.Int).Rand(rng, secp256k1P())
+	}
+	return values
+}
+
+func TestS256ElementMatchesBig(t *testing.T) {
+	p := secp256k1P()
+	rng := rand.New(rand.NewSource(1))
+	values := append(s256EdgeValues(), s256RandomValues(rng, 200)...)
+
+	check := func(op string, a, b *big.Int, got s256Element, want *big.Int) {
+		t.Helper()
+		if s256Big(got).Cmp(want) != 0 {
+			t.Fatalf("%x %s %x = %x, want %x", a, op, b, s256Big(got), want)
+		}
+	}
+
+	for _, a := range values {
+		x := s256ElementFromBig(a)
+
+		check("neg", a, a, x.neg(), new(big.Int).Mod(new(big.Int).Neg(a), p))
+		check("square", a, a, x.square(), new(big.Int).Exp(a, big.NewInt(2), p))
+
+		if a.Sign() != 0 {
+			check("inverse", a, a, x.inverse(), new(big.Int).ModInverse(a, p))
+		}
+
+		for _, b := range values {
+			y := s256ElementFromBig(b)
+
+			check("+", a, b, x.add(y), new(big.Int).Mod(new(big.Int).Add(a, b), p))
+			check("-", a, b, x.sub(y), new(big.Int).Mod(new(big.Int).Sub(a, b), p))
+			check("*", a, b, x.mul(y), new(big.Int).Mod(new(big.Int).Mul(a, b), p))
+
+			if x.equal(y) != boolUint64(a.Cmp(b) == 0) {
+				t.Fatalf("equal(%x, %x) = %d", a, b, x.equal(y))
+			}
+		}
+	}
+}
+
+func TestS256ReduceUnreducedValues(t *testing.T) {
+	p := secp256k1P()
+	max256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	// reduceOnce takes anything below 2p, p to 2^256 - 1 are not valid elements
+	for _, a := range []*big.Int{p, new(big.Int).Add(p, big.NewInt(1)), max256} {
+		got := s256ElementFromBig(a).reduceOnce(0)
+		if want := new(big.Int).Mod(a, p); s256Big(got).Cmp(want) != 0 {
+			t.Errorf("reduceOnce(%x) = %x, want %x", a, s256Big(got), want)
+		}
+	}
+
+	// full 512 bits products, including (2^256 - 1)^2 and p * 2^256
+	products := []*big.Int{
+		new(big.Int).Mul(max256, max256),
+		new(big.Int).Lsh(p, 256),
+		new(big.Int).Mul(p, p),
+		new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(p, big.NewInt(1))),
+		new(big.Int).Lsh(max256, 256),
+		max256,
+		p,
+	}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		products = append(products, new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), 512)))
+	}
+
+	for _, product := range products {
+		buf := product.FillBytes(make([]byte, 64))
+		var limbs [8]uint64
+		for i := range limbs {
+			limbs[i] = binary.BigEndian.Uint64(buf[56-8*i : 64-8*i])
+		}
+
+		got := s256Reduce(limbs)
+		if want := new(big.Int).Mod(product, p); s256Big(got).Cmp(want) != 0 {
+			t.Fatalf("s256Reduce(%x) = %x, want %x", product, s256Big(got), want)
+		}
+	}
+}
+
+func TestS256SelectAndIsZero(t *testing.T) {
+	a := s256ElementFromBig(big.NewInt(5))
+	b := s256ElementFromBig(new(big.Int).Sub(secp256k1P(), big.NewInt(1)))
+
+	if s256Select(1, a, b) != a || s256Select(0, a, b) != b {
+		t.Error("s256Select picked the wrong element")
+	}
+	if (s256Element{}).isZero() != 1 || a.isZero() != 0 || b.isZero() != 0 {
+		t.Error("isZero is wrong")
+	}
+}
+
+func boolUint64(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BenchmarkS256Mul(b *testing.B) {
+	values := s256RandomValues(rand.New(rand.NewSource(1)), 2)
+	x, y := s256ElementFromBig(values[0]), s256ElementFromBig(values[1])
+	fx, fy := S256Field(values[0]), S256Field(values[1])
+
+	b.Run("limbs", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = x.mul(y)
+		}
+	})
+
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fx = fx.mul(fy)
+		}
+	})
+}
+
+func BenchmarkS256Square(b *testing.B) {
+	values := s256RandomValues(rand.New(rand.NewSource(1)), 1)
+	x := s256ElementFromBig(values[0])
+	fx := S256Field(values[0])
+
+	b.Run("limbs", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = x.square()
+		}
+	})
+
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fx = fx.mul(fx)
+		}
+	})
+}
+
+func BenchmarkS256Inverse(b *testing.B) {
+	values := s256RandomValues(rand.New(rand.NewSource(1)), 1)
+	x := s256ElementFromBig(values[0])
+	fx := S256Field(values[0])
+
+	b.Run("limbs", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x.inverse()
+		}
+	})
+
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fx.inv()
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// mustPanic checks that f panics with the error want, the Must* wrappers
+// panic with the error of the function they wrap
+func mustPanic(t *testing.T, name string, want error, f func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !errors.Is(err, want) {
+			t.Errorf("%s: panicked with %v, want %v", name, r, want)
+		}
+	}()
+	f()
+}
+
+func TestNewFieldElementRange(t *testing.T) {
+	order := big.NewInt(13)
+
+	cases := []struct {
+		num  int64
+		want error
+	}{
+		{0, nil},
+		{12, nil},
+		{13, ErrOutOfRange},
+		{14, ErrOutOfRange},
+		{-1, ErrOutOfRange},
+	}
+
+	for _, c := range cases {
+		fe, err := NewFieldElement(order, big.NewInt(c.num))
+		if err != c.want {
+			t.Errorf("NewFieldElement(13, %d): %v, want %v", c.num, err, c.want)
+		}
+		if err == nil && fe.num.Int64() != c.num {
+			t.Errorf("NewFieldElement(13, %d) = %s", c.num, fe)
+		}
+	}
+
+	mustPanic(t, "MustFieldElement(13, 13)", ErrOutOfRange, func() {
+		MustFieldElement(order, big.NewInt(13))
+	})
+}
+
+func TestFieldElementErrors(t *testing.T) {
+	a := MustFieldElement(big.NewInt(13), big.NewInt(7))
+	b := MustFieldElement(big.NewInt(13), big.NewInt(12))
+	zero := MustFieldElement(big.NewInt(13), big.NewInt(0))
+	other := MustFieldElement(big.NewInt(17), big.NewInt(7))
+
+	cases := []struct {
+		name string
+		op   func() (*FieldElement, error)
+		want error
+		num  int64
+	}{
+		{"7 + 12", func() (*FieldElement, error) { return a.Add(b) }, nil, 6},
+		{"7 - 12", func() (*FieldElement, error) { return a.Substract(b) }, nil, 8},
+		{"7 * 12", func() (*FieldElement, error) { return a.Multiply(b) }, nil, 6},
+		{"7 / 12", func() (*FieldElement, error) { return a.Divide(b) }, nil, 6},
+		{"1 / 7", a.Inverse, nil, 2},
+		{"Add across fields", func() (*FieldElement, error) { return a.Add(other) }, ErrMismatchedField, 0},
+		{"Substract across fields", func() (*FieldElement, error) { return a.Substract(other) }, ErrMismatchedField, 0},
+		{"Multiply across fields", func() (*FieldElement, error) { return a.Multiply(other) }, ErrMismatchedField, 0},
+		{"Divide across fields", func() (*FieldElement, error) { return a.Divide(other) }, ErrMismatchedField, 0},
+		{"Divide by zero", func() (*FieldElement, error) { return a.Divide(zero) }, ErrDivisionByZero, 0},
+		{"Inverse of zero", zero.Inverse, ErrDivisionByZero, 0},
+		// 13 + 1 is not divisible by 4
+		{"Sqrt mod 13", a.Sqrt, ErrNoSquareRoot, 0},
+	}
+
+	for _, c := range cases {
+		fe, err := c.op()
+		if err != c.want {
+			t.Errorf("%s: %v, want %v", c.name, err, c.want)
+			continue
+		}
+		if err == nil && fe.num.Int64() != c.num {
+			t.Errorf("%s = %s, want %d", c.name, fe, c.num)
+		}
+	}
+}
+
+func TestOpOnFieldErrors(t *testing.T) {
+	a := MustFieldElement(big.NewInt(13), big.NewInt(7))
+	zero := MustFieldElement(big.NewInt(13), big.NewInt(0))
+	other := MustFieldElement(big.NewInt(17), big.NewInt(7))
+
+	cases := []struct {
+		name   string
+		y      *FieldElement
+		scalar *big.Int
+		opType OP_TYPE
+		want   error
+	}{
+		{"ADD across fields", other, nil, ADD, ErrMismatchedField},
+		{"SUB across fields", other, nil, SUB, ErrMismatchedField},
+		{"MUL across fields", other, nil, MUL, ErrMismatchedField},
+		{"MUL without operand", nil, nil, MUL, ErrMissingOperand},
+		{"DIV by zero", zero, nil, DIV, ErrDivisionByZero},
+		{"EXP without exponent", nil, nil, EXP, ErrMissingOperand},
+		{"unknown op", a, nil, OP_TYPE(-1), ErrUnsupportedOp},
+	}
+
+	for _, c := range cases {
+		if _, err := OpOnField(a, c.y, c.scalar, c.opType); err != c.want {
+			t.Errorf("OpOnField %s: %v, want %v", c.name, err, c.want)
+		}
+		mustPanic(t, "MustOpOnField "+c.name, c.want, func() {
+			MustOpOnField(a, c.y, c.scalar, c.opType)
+		})
+	}
+
+	if fe := MustOpOnField(a, nil, big.NewInt(2), MUL); fe.num.Int64() != 1 {
+		t.Errorf("MustOpOnField 7 * 2 = %s, want 1", fe)
+	}
+}
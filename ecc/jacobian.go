@@ -0,0 +1,250 @@
+package ecc
+
+import (
+	"math/big"
+)
+
+/*
+Jacobian coordinates
+(X, Y, Z) stands for the affine point (X / Z^2, Y / Z^3), Z = 0 is the identity point.
+
+Adding two affine points needs the slope (y2 - y1) / (x2 - x1), that is one field
+inversion (Power(p - 2)) for every addition and every doubling. In Jacobian form
+the division is carried in Z, so add and double only multiply, and we divide
+once when converting back to affine (toAffine).
+Points stay affine in the public API, ScalarMul and Verify convert to Jacobian,
+do all the additions and doublings there and convert back at the end.
+Points of the Secp256k1 curve keep X, Y and Z in s256 (fixed-width limbs)
+instead of x, y and z, every method below switches on it.
+*/
+type jacobianPoint struct {
+	curve *Curve
+	a     *FieldElement
+	b     *FieldElement
+	x     *FieldElement
+	y     *FieldElement
+	z     *FieldElement
+	s256  *s256Jacobian
+}
+
+func (p *Point) toJacobian() *jacobianPoint {
+	if p.x == nil {
+		return newJacobianIdentity(p.curve, p.a, p.b)
+	}
+
+	if p.curve == Secp256k1() {
+		return &jacobianPoint{
+			curve: p.curve,
+			a:     p.a,
+			b:     p.b,
+			s256:  &s256Jacobian{x: s256ElementFromBig(p.x.num), y: s256ElementFromBig(p.y.num), z: s256One},
+		}
+	}
+
+	return &jacobianPoint{
+		curve: p.curve,
+		a:     p.a,
+		b:     p.b,
+		x:     p.x,
+		y:     p.y,
+		z:     &FieldElement{order: p.a.order, num: big.NewInt(1)},
+	}
+}
+
+func newJacobianIdentity(curve *Curve, a *FieldElement, b *FieldElement) *jacobianPoint {
+	if curve == Secp256k1() {
+		return &jacobianPoint{curve: curve, a: a, b: b, s256: newS256JacobianIdentity()}
+	}
+
+	return &jacobianPoint{
+		curve: curve,
+		a:     a,
+		b:     b,
+		x:     &FieldElement{order: a.order, num: big.NewInt(1)},
+		y:     &FieldElement{order: a.order, num: big.NewInt(1)},
+		z:     &FieldElement{order: a.order, num: big.NewInt(0)},
+	}
+}
+
+// withS256 returns a point of the same curve as jp holding p
+func (jp *jacobianPoint) withS256(p *s256Jacobian) *jacobianPoint {
+	return &jacobianPoint{curve: jp.curve, a: jp.a, b: jp.b, s256: p}
+}
+
+// generic returns jp with x, y and z set, for mixing it with a point that is
+// not on the Secp256k1 curve object
+func (jp *jacobianPoint) generic() *jacobianPoint {
+	if jp.s256 == nil {
+		return jp
+	}
+
+	return &jacobianPoint{
+		curve: jp.curve,
+		a:     jp.a,
+		b:     jp.b,
+		x:     jp.s256.x.fieldElement(),
+		y:     jp.s256.y.fieldElement(),
+		z:     jp.s256.z.fieldElement(),
+	}
+}
+
+// x = X / Z^2, y = Y / Z^3
+func (jp *jacobianPoint) toAffine() *Point {
+	if jp.isIdentity() {
+		return &Point{curve: jp.curve, a: jp.a, b: jp.b, x: nil, y: nil}
+	}
+
+	if jp.s256 != nil {
+		x, y := jp.s256.toAffine()
+		return &Point{curve: jp.curve, a: jp.a, b: jp.b, x: x.fieldElement(), y: y.fieldElement()}
+	}
+
+	zInverse := jp.z.inv()
+	zInverse2 := zInverse.mul(zInverse)
+	zInverse3 := zInverse2.mul(zInverse)
+
+	return &Point{
+		curve: jp.curve,
+		a:     jp.a,
+		b:     jp.b,
+		x:     jp.x.mul(zInverse2),
+		y:     jp.y.mul(zInverse3),
+	}
+}
+
+func (jp *jacobianPoint) isIdentity() bool {
+	if jp.s256 != nil {
+		return jp.s256.isIdentity()
+	}
+	return jp.z.num.Sign() == 0
+}
+
+/*
+dbl-2007-bl (works for any a)
+XX = X1^2, YY = Y1^2, YYYY = YY^2, ZZ = Z1^2
+S = 4 * X1 * YY
+M = 3 * XX + a * ZZ^2
+X3 = M^2 - 2 * S
+Y3 = M * (S - X3) - 8 * YYYY
+Z3 = 2 * Y1 * Z1
+*/
+func (jp *jacobianPoint) double() *jacobianPoint {
+	if jp.s256 != nil {
+		return jp.withS256(jp.s256.double())
+	}
+
+	if jp.isIdentity() || jp.y.num.Sign() == 0 {
+		return newJacobianIdentity(jp.curve, jp.a, jp.b)
+	}
+
+	xx := jp.x.mul(jp.x)
+	yy := jp.y.mul(jp.y)
+	yyyy := yy.mul(yy)
+	zz := jp.z.mul(jp.z)
+
+	s := jp.x.mul(yy).ScalarMul(big.NewInt(4))
+	m := xx.ScalarMul(big.NewInt(3)).add(jp.a.mul(zz.mul(zz)))
+	x3 := m.mul(m).sub(s.ScalarMul(big.NewInt(2)))
+	y3 := m.mul(s.sub(x3)).sub(yyyy.ScalarMul(big.NewInt(8)))
+	z3 := jp.y.mul(jp.z).ScalarMul(big.NewInt(2))
+
+	return &jacobianPoint{curve: jp.curve, a: jp.a, b: jp.b, x: x3, y: y3, z: z3}
+}
+
+/*
+add-2007-bl
+U1 = X1 * Z2^2, U2 = X2 * Z1^2
+S1 = Y1 * Z2^3, S2 = Y2 * Z1^3
+U1 = U2 and S1 = S2 => same point, double it
+U1 = U2 and S1 != S2 => P + (-P) = identity
+H = U2 - U1, R = S2 - S1
+X3 = R^2 - H^3 - 2 * U1 * H^2
+Y3 = R * (U1 * H^2 - X3) - S1 * H^3
+Z3 = H * Z1 * Z2
+*/
+func (jp *jacobianPoint) add(other *jacobianPoint) *jacobianPoint {
+	if jp.s256 != nil && other.s256 != nil {
+		return jp.withS256(jp.s256.add(other.s256))
+	}
+	jp, other = jp.generic(), other.generic()
+
+	if jp.isIdentity() {
+		return other
+	}
+
+	if other.isIdentity() {
+		return jp
+	}
+
+	z1z1 := jp.z.mul(jp.z)
+	z2z2 := other.z.mul(other.z)
+	u1 := jp.x.mul(z2z2)
+	u2 := other.x.mul(z1z1)
+	s1 := jp.y.mul(other.z).mul(z2z2)
+	s2 := other.y.mul(jp.z).mul(z1z1)
+
+	if u1.EqualTo(u2) {
+		if s1.EqualTo(s2) {
+			return jp.double()
+		}
+		return newJacobianIdentity(jp.curve, jp.a, jp.b)
+	}
+
+	h := u2.sub(u1)
+	r := s2.sub(s1)
+	hh := h.mul(h)
+	hhh := hh.mul(h)
+	u1hh := u1.mul(hh)
+
+	x3 := r.mul(r).sub(hhh).sub(u1hh.ScalarMul(big.NewInt(2)))
+	y3 := r.mul(u1hh.sub(x3)).sub(s1.mul(hhh))
+	z3 := h.mul(jp.z).mul(other.z)
+
+	return &jacobianPoint{curve: jp.curve, a: jp.a, b: jp.b, x: x3, y: y3, z: z3}
+}
+
+// conditionalSwap swaps the two points when swap is 1 by xor-ing their fixed
+// size encodings under a mask, so both cases touch the same memory
+func conditionalSwap(p *jacobianPoint, other *jacobianPoint, swap int) (*jacobianPoint, *jacobianPoint) {
+	if p.s256 != nil && other.s256 != nil {
+		pSwapped, otherSwapped := s256ConditionalSwap(p.s256, other.s256, uint64(swap))
+		return p.withS256(pSwapped), p.withS256(otherSwapped)
+	}
+	p, other = p.generic(), other.generic()
+
+	pBytes := p.fixedBytes()
+	otherBytes := other.fixedBytes()
+	mask := byte(-swap)
+
+	for i := range pBytes {
+		t := mask & (pBytes[i] ^ otherBytes[i])
+		pBytes[i] ^= t
+		otherBytes[i] ^= t
+	}
+
+	return p.fromFixedBytes(pBytes), p.fromFixedBytes(otherBytes)
+}
+
+// fixedBytes encodes the point as X || Y || Z, each one as long as the field
+// order (32 bytes on secp256k1)
+func (jp *jacobianPoint) fixedBytes() []byte {
+	size := (jp.a.order.BitLen() + 7) / 8
+	buf := make([]byte, 3*size)
+	jp.x.num.FillBytes(buf[0:size])
+	jp.y.num.FillBytes(buf[size : 2*size])
+	jp.z.num.FillBytes(buf[2*size:])
+	return buf
+}
+
+func (jp *jacobianPoint) fromFixedBytes(buf []byte) *jacobianPoint {
+	order := jp.a.order
+	size := len(buf) / 3
+	return &jacobianPoint{
+		curve: jp.curve,
+		a:     jp.a,
+		b:     jp.b,
+		x:     &FieldElement{order: order, num: new(big.Int).SetBytes(buf[0:size])},
+		y:     &FieldElement{order: order, num: new(big.Int).SetBytes(buf[size : 2*size])},
+		z:     &FieldElement{order: order, num: new(big.Int).SetBytes(buf[2*size:])},
+	}
+}
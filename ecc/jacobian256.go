@@ -0,0 +1,115 @@
+package ecc
+
+/*
+Jacobian points of secp256k1 on s256Element, the same formulas as
+jacobianPoint but a = 0 drops a * Z^4 from the doubling (dbl-2009-l)
+
+	A = X1^2, B = Y1^2, C = B^2
+	D = 2 * ((X1 + B)^2 - A - C)
+	E = 3 * A, F = E^2
+	X3 = F - 2 * D
+	Y3 = E * (D - X3) - 8 * C
+	Z3 = 2 * Y1 * Z1
+
+jacobianPoint holds one of these for every point on the Secp256k1 curve, so
+ScalarMul, the ladder, the base table and MultiScalarMul all run on it.
+*/
+type s256Jacobian struct {
+	x s256Element
+	y s256Element
+	z s256Element
+}
+
+func newS256JacobianIdentity() *s256Jacobian {
+	return &s256Jacobian{x: s256One, y: s256One}
+}
+
+func (p *s256Jacobian) isIdentity() bool {
+	return p.z.isZero() == 1
+}
+
+func (p *s256Jacobian) double() *s256Jacobian {
+	// Z3 = 0 when p is the identity or Y1 = 0, X3 and Y3 don't matter then
+	a := p.x.square()
+	b := p.y.square()
+	c := b.square()
+	xb := p.x.add(b)
+	d := xb.square().sub(a).sub(c)
+	d = d.add(d)
+	e := a.add(a).add(a)
+	f := e.square()
+
+	x3 := f.sub(d.add(d))
+	c8 := c.add(c)
+	c8 = c8.add(c8)
+	c8 = c8.add(c8)
+	y3 := e.mul(d.sub(x3)).sub(c8)
+	yz := p.y.mul(p.z)
+	z3 := yz.add(yz)
+
+	if z3.isZero() == 1 {
+		return newS256JacobianIdentity()
+	}
+
+	return &s256Jacobian{x: x3, y: y3, z: z3}
+}
+
+// add is add-2007-bl, see jacobianPoint.add
+func (p *s256Jacobian) add(other *s256Jacobian) *s256Jacobian {
+	if p.isIdentity() {
+		return other
+	}
+
+	if other.isIdentity() {
+		return p
+	}
+
+	z1z1 := p.z.square()
+	z2z2 := other.z.square()
+	u1 := p.x.mul(z2z2)
+	u2 := other.x.mul(z1z1)
+	s1 := p.y.mul(other.z).mul(z2z2)
+	s2 := other.y.mul(p.z).mul(z1z1)
+
+	if u1.equal(u2) == 1 {
+		if s1.equal(s2) == 1 {
+			return p.double()
+		}
+		return newS256JacobianIdentity()
+	}
+
+	h := u2.sub(u1)
+	r := s2.sub(s1)
+	hh := h.square()
+	hhh := hh.mul(h)
+	u1hh := u1.mul(hh)
+
+	x3 := r.square().sub(hhh).sub(u1hh.add(u1hh))
+	y3 := r.mul(u1hh.sub(x3)).sub(s1.mul(hhh))
+	z3 := h.mul(p.z).mul(other.z)
+
+	return &s256Jacobian{x: x3, y: y3, z: z3}
+}
+
+// toAffine returns (X / Z^2, Y / Z^3), the point must not be the identity
+func (p *s256Jacobian) toAffine() (s256Element, s256Element) {
+	zInverse := p.z.inverse()
+	zInverse2 := zInverse.square()
+	zInverse3 := zInverse2.mul(zInverse)
+	return p.x.mul(zInverse2), p.y.mul(zInverse3)
+}
+
+// s256ConditionalSwap swaps p and other when swap is 1 without branching
+func s256ConditionalSwap(p *s256Jacobian, other *s256Jacobian, swap uint64) (*s256Jacobian, *s256Jacobian) {
+	swapped := &s256Jacobian{
+		x: s256Select(swap, other.x, p.x),
+		y: s256Select(swap, other.y, p.y),
+		z: s256Select(swap, other.z, p.z),
+	}
+	otherSwapped := &s256Jacobian{
+		x: s256Select(swap, p.x, other.x),
+		y: s256Select(swap, p.y, other.y),
+		z: s256Select(swap, p.z, other.z),
+	}
+	return swapped, otherSwapped
+}
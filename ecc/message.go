@@ -0,0 +1,141 @@
+package ecc
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+/*
+Bitcoin Signed Message (BIP137)
+
+The signed hash is Hash256("\x18Bitcoin Signed Message:\n" || varint(len(message)) || message)
+so a message signature can never be a valid transaction signature.
+The signature is a compact signature (see recovery.go) in base64, the header
+byte tells the verifier which address the recovered key has to match:
+	27 - 30 P2PKH uncompressed
+	31 - 34 P2PKH compressed
+	35 - 38 P2SH-P2WPKH
+	39 - 42 P2WPKH
+*/
+
+const messageMagic = "\x18Bitcoin Signed Message:\n"
+
+var (
+	ErrMessageSigEncoding    = errors.New("message signature is not valid base64")
+	ErrMessageSigHeader      = errors.New("message signature header must be between 27 and 42")
+	ErrSegwitNeedsCompressed = errors.New("segwit addresses need a compressed public key")
+	ErrUnsupportedAddress    = errors.New("address type is not supported")
+)
+
+// MessageHash returns the hash signed for a Bitcoin Signed Message
+func MessageHash(message string) []byte {
+	prefixed := messageMagic + string(encodeMessageVarint(len(message))) + message
+	return Hash256(prefixed)
+}
+
+// SignMessage signs message for the address of the given type, compressed is
+// only used for P2PKH, segwit keys are always compressed
+func (pk *PrivateKey) SignMessage(message string, addrType ADDRESS_TYPE, compressed bool) (string, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return "", err
+	}
+
+	header := byte(compactSigHeader)
+
+	switch addrType {
+	case P2PKH:
+		if compressed {
+			header += 4
+		}
+	case P2SH_P2WPKH:
+		if !compressed {
+			return "", ErrSegwitNeedsCompressed
+		}
+		header += 8
+	case P2WPKH:
+		if !compressed {
+			return "", ErrSegwitNeedsCompressed
+		}
+		header += 12
+	default:
+		return "", ErrUnsupportedAddress
+	}
+
+	e := Secp256k1().ScalarFromHash(MessageHash(message))
+	sig, recID, err := pk.sign(e, nil)
+	if err != nil {
+		return "", err
+	}
+	compact := sig.Compact(0, false)
+	compact[0] = header + recID
+
+	return base64.StdEncoding.EncodeToString(compact), nil
+}
+
+// VerifyMessage checks that signature (base64) was made for message by the
+// key of address, mainnet and testnet addresses are both accepted
+func VerifyMessage(address string, message string, signature string) (bool, error) {
+	compact, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, ErrMessageSigEncoding
+	}
+
+	if len(compact) != 65 {
+		return false, ErrCompactSigLength
+	}
+
+	header := compact[0]
+	if header < compactSigHeader || header > compactSigHeader+15 {
+		return false, ErrMessageSigHeader
+	}
+
+	// turn it into a plain compact signature header (27 - 34) to parse it
+	flags := header - compactSigHeader
+	compact[0] = compactSigHeader + flags&3
+	sig, recID, _, err := ParseCompact(compact)
+	if err != nil {
+		return false, err
+	}
+
+	e := Secp256k1().ScalarFromHash(MessageHash(message))
+	Q, err := RecoverPublicKey(e, sig, recID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, testnet := range []bool{false, true} {
+		var expected string
+
+		switch flags >> 2 {
+		case 0:
+			expected = Q.Address(false, testnet)
+		case 1:
+			expected = Q.Address(true, testnet)
+		case 2:
+			expected = Q.P2SHP2WPKHAddress(testnet)
+		case 3:
+			expected = Q.P2WPKHAddress(testnet)
+			// bech32 addresses may also be written in upper case
+			address = strings.ToLower(address)
+		}
+
+		if expected == address {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// encodeMessageVarint encodes the message length as a Bitcoin varint
+func encodeMessageVarint(length int) []byte {
+	switch {
+	case length < 0xfd:
+		return []byte{byte(length)}
+	case length <= 0xffff:
+		return []byte{0xfd, byte(length), byte(length >> 8)}
+	default:
+		return []byte{0xfe, byte(length), byte(length >> 8), byte(length >> 16), byte(length >> 24)}
+	}
+}
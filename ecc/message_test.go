@@ -0,0 +1,117 @@
+package ecc
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Electrum's test_sign_message, the signatures are deterministic (RFC 6979)
+var messageVectors = []struct {
+	wif       string
+	address   string
+	message   string
+	signature string
+}{
+	{
+		wif:       "L1TnU2zbNaAqMoVh65Cyvmcjzbrj41Gs9iTLcWbpJCMynXuap6UN",
+		address:   "15hETetDmcXm1mM4sEf7U2KXC9hDHFMSzz",
+		message:   "Chancellor on brink of second bailout for banks",
+		signature: "H/9jMOnj4MFbH3d7t4yCQ9i7DgZU/VZ278w3+ySv2F4yIsdqjsc5ng3kmN8OZAThgyfCZOQxZCWza9V5XzlVY0Y=",
+	},
+	{
+		wif:       "5Hxn5C4SQuiV6e62A1MtZmbSeQyrLFhu5uYks62pU5VBUygK2KD",
+		address:   "1GPHVTY8UD9my6jyP4tb2TYJwUbDetyNC6",
+		message:   "Electrum",
+		signature: "G84dmJ8TKIDKMT9qBRhpX2sNmR0y5t+POcYnFFJCs66lJmAs3T8A6Sbpx7KA6yTQ9djQMabwQXRrDomOkIKGn18=",
+	},
+}
+
+func TestMessageVectors(t *testing.T) {
+	for _, v := range messageVectors {
+		ok, err := VerifyMessage(v.address, v.message, v.signature)
+		if !ok || err != nil {
+			t.Errorf("%s: VerifyMessage = %v, %v", v.address, ok, err)
+		}
+
+		pk, compressed, _, err := ParseWIF(v.wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := pk.SignMessage(v.message, P2PKH, compressed)
+		if err != nil || signature != v.signature {
+			t.Errorf("%s: SignMessage = %s, %v, want %s", v.address, signature, err, v.signature)
+		}
+
+		// the signature of one message does not verify another one
+		if ok, _ := VerifyMessage(v.address, v.message+".", v.signature); ok {
+			t.Errorf("%s: signature verifies for another message", v.address)
+		}
+	}
+}
+
+func TestSignMessageSegwit(t *testing.T) {
+	pk := MustPrivateKey(big.NewInt(0xc0ffee))
+	other := MustPrivateKey(big.NewInt(0xbeef)).Public()
+	message := "segwit message"
+
+	cases := []struct {
+		addrType ADDRESS_TYPE
+		address  string
+		wrong    string
+	}{
+		{P2SH_P2WPKH, pk.Public().P2SHP2WPKHAddress(false), other.P2SHP2WPKHAddress(false)},
+		{P2SH_P2WPKH, pk.Public().P2SHP2WPKHAddress(true), other.P2SHP2WPKHAddress(true)},
+		{P2WPKH, pk.Public().P2WPKHAddress(false), other.P2WPKHAddress(false)},
+		{P2WPKH, strings.ToUpper(pk.Public().P2WPKHAddress(false)), other.P2WPKHAddress(false)},
+		{P2WPKH, pk.Public().P2WPKHAddress(true), other.P2WPKHAddress(true)},
+	}
+	for _, c := range cases {
+		signature, err := pk.SignMessage(message, c.addrType, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := VerifyMessage(c.address, message, signature); !ok || err != nil {
+			t.Errorf("%s: VerifyMessage = %v, %v", c.address, ok, err)
+		}
+		if ok, err := VerifyMessage(c.wrong, message, signature); ok || err != nil {
+			t.Errorf("%s: signature verifies for %s (%v)", c.address, c.wrong, err)
+		}
+
+		// the header tells the address type, the P2PKH address of the key does not match
+		if ok, _ := VerifyMessage(pk.Public().Address(true, false), message, signature); ok {
+			t.Errorf("%s: signature verifies for the P2PKH address", c.address)
+		}
+	}
+}
+
+func TestSignMessageErrors(t *testing.T) {
+	pk := MustPrivateKey(big.NewInt(1))
+
+	for _, addrType := range []ADDRESS_TYPE{P2SH_P2WPKH, P2WPKH} {
+		if _, err := pk.SignMessage("m", addrType, false); err != ErrSegwitNeedsCompressed {
+			t.Errorf("type %d uncompressed: %v, want ErrSegwitNeedsCompressed", addrType, err)
+		}
+	}
+	if _, err := pk.SignMessage("m", P2TR, true); err != ErrUnsupportedAddress {
+		t.Errorf("P2TR: %v, want ErrUnsupportedAddress", err)
+	}
+
+	address := messageVectors[0].address
+	header43 := "K" + messageVectors[0].signature[1:]
+	cases := []struct {
+		name      string
+		signature string
+		err       error
+	}{
+		{"base64", "not base64!", ErrMessageSigEncoding},
+		{"length", "AAAA", ErrCompactSigLength},
+		{"header", header43, ErrMessageSigHeader},
+	}
+	for _, c := range cases {
+		if _, err := VerifyMessage(address, "m", c.signature); err != c.err {
+			t.Errorf("%s: %v, want %v", c.name, err, c.err)
+		}
+	}
+}
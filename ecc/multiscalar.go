@@ -0,0 +1,133 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+/*
+Multi-scalar multiplication (Strauss / Shamir's trick)
+k1*P1 + k2*P2 + ... + km*Pm
+
+Computing every ki*Pi on its own and adding them at the end does the 256
+doublings m times. Since doubling a sum is the sum of the doubled points, we
+can share the doublings and walk all the scalars at the same time, 4 bits
+(one window) at a time:
+	table of every point: Pi, 2Pi, 3Pi, ..., 15Pi
+	R = identity
+	for every window from the highest to the lowest:
+		R = 16R (4 doublings)
+		R = R + table_i[window of ki] for every point
+So the doublings are done once for all points, and each point costs one
+addition per window instead of one per set bit.
+The windows are read from the bits of |ki|, a negative ki is turned into
+|ki| * (-Pi) first.
+Verify always passes G, on secp256k1 its table is built once (like baseTable)
+instead of doing the 15 additions on every call.
+The running time depends on the scalars, only use it with public values.
+*/
+
+const multiWindowBits = 4
+
+var (
+	ErrScalarCountMismatch = errors.New("number of scalars and points are not equal")
+	ErrNoPoints            = errors.New("need at least one point")
+)
+
+func MultiScalarMul(scalars []*big.Int, points []*Point) (*Point, error) {
+	if len(scalars) != len(points) {
+		return nil, ErrScalarCountMismatch
+	}
+
+	if len(points) == 0 {
+		return nil, ErrNoPoints
+	}
+
+	for i, point := range points {
+		if scalars[i] == nil {
+			return nil, ErrMissingOperand
+		}
+
+		if !point.a.EqualTo(points[0].a) || !point.b.EqualTo(points[0].b) {
+			return nil, ErrDifferentCurves
+		}
+	}
+
+	// k*P = (-k)*(-P), multiScalarMulJacobian only reads the magnitude
+	absScalars := make([]*big.Int, len(scalars))
+	signedPoints := make([]*Point, len(points))
+	for i, scalar := range scalars {
+		absScalars[i] = scalar
+		signedPoints[i] = points[i]
+		if scalar.Sign() < 0 {
+			absScalars[i] = new(big.Int).Neg(scalar)
+			signedPoints[i] = points[i].negate()
+		}
+	}
+
+	return multiScalarMulJacobian(absScalars, signedPoints).toAffine(), nil
+}
+
+// multiScalarMulJacobian expects inputs already checked by MultiScalarMul and
+// scalars that are not negative
+func multiScalarMulJacobian(scalars []*big.Int, points []*Point) *jacobianPoint {
+	maxBits := 0
+	tables := make([][]*jacobianPoint, len(points))
+
+	for i, point := range points {
+		if scalars[i].BitLen() > maxBits {
+			maxBits = scalars[i].BitLen()
+		}
+
+		tables[i] = pointMultiples(point)
+	}
+
+	result := newJacobianIdentity(points[0].curve, points[0].a, points[0].b)
+	windows := (maxBits + multiWindowBits - 1) / multiWindowBits
+
+	for w := windows - 1; w >= 0; w-- {
+		for j := 0; j < multiWindowBits; j++ {
+			result = result.double()
+		}
+
+		for i, scalar := range scalars {
+			window := 0
+			for j := multiWindowBits - 1; j >= 0; j-- {
+				window = window<<1 | int(scalar.Bit(w*multiWindowBits+j))
+			}
+
+			if window != 0 {
+				result = result.add(tables[i][window])
+			}
+		}
+	}
+
+	return result
+}
+
+// generatorMultiples is the table of the secp256k1 generator
+var generatorMultiples = sync.OnceValue(func() []*jacobianPoint {
+	return multiplesTable(GeneratorPoint())
+})
+
+// pointMultiples returns the cached table when p is the secp256k1 generator
+func pointMultiples(p *Point) []*jacobianPoint {
+	if p.curve == Secp256k1() && p.Equal(GeneratorPoint()) {
+		return generatorMultiples()
+	}
+	return multiplesTable(p)
+}
+
+// multiplesTable returns 0P, 1P, 2P, ..., 15P
+func multiplesTable(p *Point) []*jacobianPoint {
+	table := make([]*jacobianPoint, 1<<multiWindowBits)
+	table[0] = newJacobianIdentity(p.curve, p.a, p.b)
+	table[1] = p.toJacobian()
+
+	for i := 2; i < len(table); i++ {
+		table[i] = table[i-1].add(table[1])
+	}
+
+	return table
+}
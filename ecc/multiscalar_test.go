@@ -0,0 +1,102 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestMultiScalarMulNegativeScalars(t *testing.T) {
+	G := GeneratorPoint()
+	n := BitcoinN()
+
+	got, err := MultiScalarMul([]*big.Int{big.NewInt(-5)}, []*Point{G})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := G.ScalarMul(new(big.Int).Sub(n, big.NewInt(5)))
+	if !got.Equal(want) {
+		t.Fatalf("-5G = %s, want %s", got, want)
+	}
+
+	// Q = 11G, 7G - 3Q + 0G - 2n*Q = -26G
+	Q := G.ScalarMul(big.NewInt(11))
+	got, err = MultiScalarMul(
+		[]*big.Int{big.NewInt(7), big.NewInt(-3), big.NewInt(0), new(big.Int).Neg(new(big.Int).Lsh(n, 1))},
+		[]*Point{G, Q, G, Q},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = G.ScalarMul(new(big.Int).Sub(n, big.NewInt(26)))
+	if !got.Equal(want) {
+		t.Fatalf("7G - 3Q = %s, want %s", got, want)
+	}
+}
+
+func TestMultiScalarMulMatchesScalarMul(t *testing.T) {
+	G := GeneratorPoint()
+	points := []*Point{G, G.ScalarMul(big.NewInt(2)), G.ScalarMul(big.NewInt(12345))}
+
+	for seed := int64(0); seed < 20; seed++ {
+		scalars := make([]*big.Int, len(points))
+		want := Secp256k1().Identity()
+		for i := range points {
+			k := new(big.Int).SetBytes(Hash256(string(rune('a'+seed)) + string(rune('0'+i))))
+			if (seed+int64(i))%2 == 1 {
+				k.Neg(k)
+			}
+			scalars[i] = k
+
+			term := points[i].ScalarMul(new(big.Int).Abs(k))
+			if k.Sign() < 0 {
+				term = term.negate()
+			}
+			want = want.MustAdd(term)
+		}
+
+		got, err := MultiScalarMul(scalars, points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("seed %d: got %s, want %s", seed, got, want)
+		}
+	}
+}
+
+func TestGeneratorMultiples(t *testing.T) {
+	G := GeneratorPoint()
+
+	table := generatorMultiples()
+	if &generatorMultiples()[0] != &table[0] {
+		t.Error("generator table is rebuilt")
+	}
+	for i, entry := range table {
+		if want := G.ScalarMul(big.NewInt(int64(i))); !entry.toAffine().Equal(want) {
+			t.Errorf("table[%d] = %s, want %s", i, entry.toAffine(), want)
+		}
+	}
+
+	// a copy of G made by hand uses the table too
+	handMade := S256Point(G.x.num, G.y.num)
+	if &pointMultiples(handMade)[0] != &table[0] {
+		t.Error("hand made generator does not use the cached table")
+	}
+	if &pointMultiples(G.ScalarMul(big.NewInt(2)))[0] == &table[0] {
+		t.Error("2G uses the generator table")
+	}
+	if &pointMultiples(P256().Generator())[0] == &table[0] {
+		t.Error("the P-256 generator uses the secp256k1 table")
+	}
+
+	k := hexInt("8a5f2b9c0d3e4f61728394a5b6c7d8e9fa0b1c2d3e4f5061728394a5b6c7d8e9")
+	for _, point := range []*Point{G, handMade} {
+		got, err := MultiScalarMul([]*big.Int{k, big.NewInt(3)}, []*Point{point, G})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := G.ScalarMul(new(big.Int).Add(k, big.NewInt(3))); !got.Equal(want) {
+			t.Errorf("kG + 3G = %s, want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,578 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+/*
+MuSig2 (BIP327), n-of-n Schnorr multi-signatures
+
+u signers with public keys pk1..pku produce one BIP340 signature valid for an
+aggregated key Q, on chain it looks like a single key.
+
+Key aggregation
+	L = hash_KeyAgg list(pk1 || ... || pku)
+	ai = hash_KeyAgg coefficient(L || pki), ai = 1 for the second distinct key
+	Q = a1*P1 + ... + au*Pu
+Tweaks (BIP32 plain or taproot x-only) move Q, gacc and tacc remember how
+the tweaks changed the sign and added to the key so signers can follow.
+
+Signing takes two rounds
+1. every signer makes two secret nonces k1, k2 and publishes R1 = k1*G, R2 = k2*G,
+   the public nonces are summed into the aggregated nonce
+2. b = hash_MuSig/noncecoef(aggnonce || x(Q) || m), R = R1 + b*R2
+   e = hash_BIP0340/challenge(x(R) || x(Q) || m)
+   every signer sends s = k1 + b*k2 + e*a*d mod n (partial signature)
+   and the sum of the partial signatures is the s of the final signature (x(R), s)
+
+Public keys are 33 bytes compressed SEC, public nonces are two compressed
+points (66 bytes), the secret nonce is k1 || k2 || pk (97 bytes).
+*/
+
+var (
+	ErrMuSig2TweakOutOfRange   = errors.New("the tweak must be less than n")
+	ErrMuSig2TweakInfinity     = errors.New("the result of tweaking cannot be infinity")
+	ErrMuSig2KeyAggInfinity    = errors.New("the aggregated key cannot be infinity")
+	ErrMuSig2SignerNotInKeys   = errors.New("the signer's pubkey must be included in the list of pubkeys")
+	ErrMuSig2SecNonceRange     = errors.New("secnonce value is out of range")
+	ErrMuSig2SecNonceMismatch  = errors.New("secnonce does not belong to the secret key")
+	ErrMuSig2SecretKeyRange    = errors.New("secret key is out of range")
+	ErrMuSig2NonceGenFailed    = errors.New("nonce generation produced a zero nonce")
+	ErrMuSig2RandLength        = errors.New("nonce generation randomness must be 32 bytes")
+	ErrMuSig2InvalidPartialSig = errors.New("produced partial signature does not verify")
+	ErrMuSig2PubKeyNotInKeys   = errors.New("the pubkey of the partial signature is not in the list of pubkeys")
+	ErrMuSig2NonceCount        = errors.New("there must be one pubnonce per pubkey")
+	ErrMuSig2SignerIndex       = errors.New("signer index is out of range of the pubkeys")
+)
+
+// MuSig2AggregatorIndex is the Signer of an invalid contribution made by the
+// nonce aggregator (the aggregated nonce) instead of one of the signers
+const MuSig2AggregatorIndex = -1
+
+// MuSig2InvalidContributionError blames the signer (index in the list of
+// public keys or nonces) who sent an invalid public key, nonce or partial signature
+type MuSig2InvalidContributionError struct {
+	Signer       int
+	Contribution string
+}
+
+func (err *MuSig2InvalidContributionError) Error() string {
+	if err.Signer == MuSig2AggregatorIndex {
+		return fmt.Sprintf("invalid %s from the aggregator", err.Contribution)
+	}
+	return fmt.Sprintf("invalid %s from signer %d", err.Contribution, err.Signer)
+}
+
+type MuSig2KeyAggContext struct {
+	q    *Point
+	gacc *Scalar
+	tacc *Scalar
+}
+
+// MuSig2KeySort sorts the public keys lexicographically
+func MuSig2KeySort(pubKeys [][]byte) [][]byte {
+	sorted := make([][]byte, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+func MuSig2KeyAgg(pubKeys [][]byte) (*MuSig2KeyAggContext, error) {
+	points := make([]*Point, len(pubKeys))
+	scalars := make([]*big.Int, len(pubKeys))
+
+	for i, pubKey := range pubKeys {
+		P, err := cpoint(pubKey)
+		if err != nil {
+			return nil, &MuSig2InvalidContributionError{Signer: i, Contribution: "pubkey"}
+		}
+		points[i] = P
+		scalars[i] = keyAggCoeff(pubKeys, pubKey).num
+	}
+
+	if len(points) == 0 {
+		return nil, ErrMuSig2KeyAggInfinity
+	}
+
+	Q := multiScalarMulJacobian(scalars, points).toAffine()
+	if Q.x == nil {
+		return nil, ErrMuSig2KeyAggInfinity
+	}
+
+	c := Secp256k1()
+	return &MuSig2KeyAggContext{q: Q, gacc: c.scalar(big.NewInt(1)), tacc: c.scalar(big.NewInt(0))}, nil
+}
+
+/*
+ApplyTweak
+g = n - 1 when the tweak is x-only and Q has an odd y (we work with the even Q), 1 otherwise
+Q' = g*Q + t*G, gacc' = g*gacc, tacc' = t + g*tacc
+*/
+func (ctx *MuSig2KeyAggContext) ApplyTweak(tweak []byte, xOnly bool) (*MuSig2KeyAggContext, error) {
+	c := Secp256k1()
+	g := c.scalar(big.NewInt(1))
+	if xOnly && !ctx.q.hasEvenY() {
+		g = g.neg()
+	}
+
+	t, err := c.NewScalar(new(big.Int).SetBytes(tweak))
+	if len(tweak) != 32 || err != nil {
+		return nil, ErrMuSig2TweakOutOfRange
+	}
+
+	Q := multiScalarMulJacobian([]*big.Int{g.num, t.num}, []*Point{ctx.q, GeneratorPoint()}).toAffine()
+	if Q.x == nil {
+		return nil, ErrMuSig2TweakInfinity
+	}
+
+	return &MuSig2KeyAggContext{q: Q, gacc: g.mul(ctx.gacc), tacc: g.mul(ctx.tacc).add(t)}, nil
+}
+
+// AggregatedKey returns Q, use XOnly for the taproot output key
+func (ctx *MuSig2KeyAggContext) AggregatedKey() *Point {
+	return ctx.q
+}
+
+func keyAggCoeff(pubKeys [][]byte, pubKey []byte) *Scalar {
+	if bytes.Equal(pubKey, secondKey(pubKeys)) {
+		return Secp256k1().scalar(big.NewInt(1))
+	}
+
+	L := TaggedHash("KeyAgg list", pubKeys...)
+	return Secp256k1().scalar(new(big.Int).SetBytes(TaggedHash("KeyAgg coefficient", L, pubKey)))
+}
+
+// secondKey is the first key different from the first one, 33 zero bytes when there is none
+func secondKey(pubKeys [][]byte) []byte {
+	for _, pubKey := range pubKeys[1:] {
+		if !bytes.Equal(pubKey, pubKeys[0]) {
+			return pubKey
+		}
+	}
+	return make([]byte, 33)
+}
+
+/*
+MuSig2NonceGen
+rand = sk xor hash_MuSig/aux(rand') when the secret key is given, rand' otherwise
+ki = hash_MuSig/nonce(rand || len(pk) || pk || len(aggpk) || aggpk || m_prefixed || len(extra) || extra || i - 1) mod n
+m_prefixed = 0x00 when there is no message, 0x01 || len(m) (8 bytes) || m otherwise
+
+randBytes must be 32 fresh random bytes, every other input is optional and
+only adds to the randomness (nil when not known), msg = nil means no message
+while an empty non-nil msg is the empty message.
+The secret nonce must be used for one signature only, Sign clears it.
+*/
+func MuSig2NonceGen(
+	randBytes []byte,
+	secretKey *PrivateKey,
+	pubKey []byte,
+	aggPubKey []byte,
+	msg []byte,
+	extraIn []byte,
+) (secNonce []byte, pubNonce []byte, err error) {
+	if len(randBytes) != 32 {
+		return nil, nil, ErrMuSig2RandLength
+	}
+
+	random := randBytes
+	if secretKey != nil {
+		random = secretKey.d.Bytes()
+		auxHash := TaggedHash("MuSig/aux", randBytes)
+		for i := range random {
+			random[i] ^= auxHash[i]
+		}
+	}
+
+	msgPrefixed := []byte{0x00}
+	if msg != nil {
+		msgPrefixed = []byte{0x01}
+		msgPrefixed = binary.BigEndian.AppendUint64(msgPrefixed, uint64(len(msg)))
+		msgPrefixed = append(msgPrefixed, msg...)
+	}
+
+	c := Secp256k1()
+	secNonce = []byte{}
+	pubNonce = []byte{}
+
+	for i := 0; i < 2; i++ {
+		k := c.scalar(new(big.Int).SetBytes(TaggedHash(
+			"MuSig/nonce",
+			random,
+			[]byte{byte(len(pubKey))},
+			pubKey,
+			[]byte{byte(len(aggPubKey))},
+			aggPubKey,
+			msgPrefixed,
+			binary.BigEndian.AppendUint32(nil, uint32(len(extraIn))),
+			extraIn,
+			[]byte{byte(i)},
+		)))
+		if k.IsZero() {
+			return nil, nil, ErrMuSig2NonceGenFailed
+		}
+
+		_, R := baseMul(k.num).SEC(true)
+		secNonce = append(secNonce, k.Bytes()...)
+		pubNonce = append(pubNonce, R...)
+	}
+
+	secNonce = append(secNonce, pubKey...)
+	return secNonce, pubNonce, nil
+}
+
+// MuSig2NonceAgg sums the first and the second points of every public nonce
+func MuSig2NonceAgg(pubNonces [][]byte) ([]byte, error) {
+	aggNonce := []byte{}
+
+	for j := 0; j < 2; j++ {
+		var sum *jacobianPoint
+		for i, pubNonce := range pubNonces {
+			if len(pubNonce) != 66 {
+				return nil, &MuSig2InvalidContributionError{Signer: i, Contribution: "pubnonce"}
+			}
+
+			R, err := cpoint(pubNonce[j*33 : (j+1)*33])
+			if err != nil {
+				return nil, &MuSig2InvalidContributionError{Signer: i, Contribution: "pubnonce"}
+			}
+
+			if sum == nil {
+				sum = R.toJacobian()
+			} else {
+				sum = sum.add(R.toJacobian())
+			}
+		}
+
+		if sum == nil {
+			return nil, &MuSig2InvalidContributionError{Signer: MuSig2AggregatorIndex, Contribution: "pubnonce"}
+		}
+		aggNonce = append(aggNonce, cbytesExt(sum.toAffine())...)
+	}
+
+	return aggNonce, nil
+}
+
+// MuSig2Session holds what every signer agrees on for one signature
+type MuSig2Session struct {
+	aggNonce []byte
+	pubKeys  [][]byte
+	tweaks   [][]byte
+	isXOnly  []bool
+	msg      []byte
+}
+
+func NewMuSig2Session(aggNonce []byte, pubKeys [][]byte, tweaks [][]byte, isXOnly []bool, msg []byte) *MuSig2Session {
+	return &MuSig2Session{
+		aggNonce: aggNonce,
+		pubKeys:  pubKeys,
+		tweaks:   tweaks,
+		isXOnly:  isXOnly,
+		msg:      msg,
+	}
+}
+
+type muSig2SessionValues struct {
+	keyAgg *MuSig2KeyAggContext
+	b      *Scalar
+	R      *Point
+	e      *Scalar
+}
+
+func (s *MuSig2Session) values() (*muSig2SessionValues, error) {
+	if len(s.tweaks) != len(s.isXOnly) {
+		return nil, errors.New("the number of tweaks and tweak modes must be equal")
+	}
+
+	keyAgg, err := MuSig2KeyAgg(s.pubKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tweak := range s.tweaks {
+		keyAgg, err = keyAgg.ApplyTweak(tweak, s.isXOnly[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.aggNonce) != 66 {
+		return nil, &MuSig2InvalidContributionError{Signer: MuSig2AggregatorIndex, Contribution: "aggnonce"}
+	}
+
+	R1, err := cpointExt(s.aggNonce[:33])
+	if err != nil {
+		return nil, &MuSig2InvalidContributionError{Signer: MuSig2AggregatorIndex, Contribution: "aggnonce"}
+	}
+
+	R2, err := cpointExt(s.aggNonce[33:])
+	if err != nil {
+		return nil, &MuSig2InvalidContributionError{Signer: MuSig2AggregatorIndex, Contribution: "aggnonce"}
+	}
+
+	Qx := keyAgg.q.XOnly()
+	b := Secp256k1().scalar(new(big.Int).SetBytes(TaggedHash("MuSig/noncecoef", s.aggNonce, Qx, s.msg)))
+
+	// R = R1 + b*R2, G when it is the identity point
+	R := R1.toJacobian().add(R2.scalarMulJacobian(b.num)).toAffine()
+	if R.x == nil {
+		R = GeneratorPoint()
+	}
+
+	e := schnorrChallenge(R.XOnly(), Qx, s.msg)
+
+	return &muSig2SessionValues{keyAgg: keyAgg, b: b, R: R, e: e}, nil
+}
+
+func (s *MuSig2Session) keyAggCoeff(pubKey []byte) (*Scalar, error) {
+	for _, key := range s.pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return keyAggCoeff(s.pubKeys, pubKey), nil
+		}
+	}
+	return nil, ErrMuSig2SignerNotInKeys
+}
+
+/*
+Sign makes the partial signature (32 bytes) of secretKey
+k1, k2 = n - k1, n - k2 when R has an odd y
+d = g * gacc * d (g = n - 1 when Q has an odd y)
+s = k1 + b*k2 + e*a*d mod n
+secNonce is cleared so the same nonce can't be used twice.
+*/
+func (s *MuSig2Session) Sign(secNonce []byte, secretKey *PrivateKey) ([]byte, error) {
+	values, err := s.values()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secNonce) != 97 {
+		return nil, ErrMuSig2SecNonceRange
+	}
+
+	c := Secp256k1()
+	k1, errK1 := c.NewScalar(new(big.Int).SetBytes(secNonce[:32]))
+	k2, errK2 := c.NewScalar(new(big.Int).SetBytes(secNonce[32:64]))
+	secNoncePubKey := append([]byte{}, secNonce[64:]...)
+	for i := range secNonce[:64] {
+		secNonce[i] = 0
+	}
+
+	if errK1 != nil || errK2 != nil || k1.IsZero() || k2.IsZero() {
+		return nil, ErrMuSig2SecNonceRange
+	}
+
+	pubNonce := append(cbytesExt(baseMul(k1.num)), cbytesExt(baseMul(k2.num))...)
+	if !values.R.hasEvenY() {
+		k1, k2 = k1.neg(), k2.neg()
+	}
+
+	if secretKey.check() != nil {
+		return nil, ErrMuSig2SecretKeyRange
+	}
+
+	if secretKey.Q.curve != Secp256k1() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	_, pubKey := secretKey.Q.SEC(true)
+	if !bytes.Equal(pubKey, secNoncePubKey) {
+		return nil, ErrMuSig2SecNonceMismatch
+	}
+
+	a, err := s.keyAggCoeff(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	d := values.keyAgg.gacc.mul(secretKey.d)
+	if !values.keyAgg.q.hasEvenY() {
+		d = d.neg()
+	}
+
+	sig := values.e.mul(a).mul(d).add(k1).add(values.b.mul(k2))
+
+	partialSig := sig.Bytes()
+	if ok, _ := s.partialSigVerify(values, partialSig, pubNonce, pubKey); !ok {
+		return nil, ErrMuSig2InvalidPartialSig
+	}
+
+	return partialSig, nil
+}
+
+// PartialSigVerify checks the partial signature of the signer with the given
+// public nonce and public key, an error tells which contribution was invalid
+func (s *MuSig2Session) PartialSigVerify(partialSig []byte, pubNonce []byte, pubKey []byte) (bool, error) {
+	values, err := s.values()
+	if err != nil {
+		return false, err
+	}
+
+	return s.partialSigVerify(values, partialSig, pubNonce, pubKey)
+}
+
+/*
+s*G = Re + e*a*g'*P
+Re = R1 + b*R2 of the signer, negated when R has an odd y
+g' = g * gacc (g = n - 1 when Q has an odd y)
+*/
+func (s *MuSig2Session) partialSigVerify(values *muSig2SessionValues, partialSig []byte, pubNonce []byte, pubKey []byte) (bool, error) {
+	c := Secp256k1()
+	sig, err := c.NewScalar(new(big.Int).SetBytes(partialSig))
+	if len(partialSig) != 32 || err != nil {
+		return false, nil
+	}
+
+	// pubkeys.index(pk) of BIP327, an unknown key can't be blamed on anyone
+	signer := -1
+	for i, key := range s.pubKeys {
+		if bytes.Equal(key, pubKey) {
+			signer = i
+			break
+		}
+	}
+
+	if signer < 0 {
+		return false, ErrMuSig2PubKeyNotInKeys
+	}
+
+	if len(pubNonce) != 66 {
+		return false, &MuSig2InvalidContributionError{Signer: signer, Contribution: "pubnonce"}
+	}
+
+	R1, err := cpoint(pubNonce[:33])
+	if err != nil {
+		return false, &MuSig2InvalidContributionError{Signer: signer, Contribution: "pubnonce"}
+	}
+
+	R2, err := cpoint(pubNonce[33:])
+	if err != nil {
+		return false, &MuSig2InvalidContributionError{Signer: signer, Contribution: "pubnonce"}
+	}
+
+	P, err := cpoint(pubKey)
+	if err != nil {
+		return false, &MuSig2InvalidContributionError{Signer: signer, Contribution: "pubkey"}
+	}
+
+	a, err := s.keyAggCoeff(pubKey)
+	if err != nil {
+		return false, err
+	}
+
+	g := values.keyAgg.gacc
+	if !values.keyAgg.q.hasEvenY() {
+		g = g.neg()
+	}
+	eag := values.e.mul(a).mul(g)
+
+	// s*G - Re - e*a*g'*P has to be the identity point
+	b := values.b
+	one := c.scalar(big.NewInt(1))
+	if values.R.hasEvenY() {
+		one = one.neg()
+		b = b.neg()
+	}
+
+	total := multiScalarMulJacobian(
+		[]*big.Int{sig.num, one.num, b.num, eag.neg().num},
+		[]*Point{GeneratorPoint(), R1, R2, P},
+	)
+
+	return total.isIdentity(), nil
+}
+
+// MuSig2PartialSigVerify builds the session from the public nonces of all
+// signers and checks the partial signature of the signer at index i
+func MuSig2PartialSigVerify(
+	partialSig []byte,
+	pubNonces [][]byte,
+	pubKeys [][]byte,
+	tweaks [][]byte,
+	isXOnly []bool,
+	msg []byte,
+	i int,
+) (bool, error) {
+	if len(pubNonces) != len(pubKeys) {
+		return false, ErrMuSig2NonceCount
+	}
+
+	if i < 0 || i >= len(pubKeys) {
+		return false, ErrMuSig2SignerIndex
+	}
+
+	aggNonce, err := MuSig2NonceAgg(pubNonces)
+	if err != nil {
+		return false, err
+	}
+
+	session := NewMuSig2Session(aggNonce, pubKeys, tweaks, isXOnly, msg)
+	return session.PartialSigVerify(partialSig, pubNonces[i], pubKeys[i])
+}
+
+// PartialSigAgg sums the partial signatures into the final BIP340 signature
+// s = s1 + ... + su + e*g*tacc
+func (s *MuSig2Session) PartialSigAgg(partialSigs [][]byte) (*SchnorrSignature, error) {
+	values, err := s.values()
+	if err != nil {
+		return nil, err
+	}
+
+	c := Secp256k1()
+	sum := c.scalar(big.NewInt(0))
+	for i, partialSig := range partialSigs {
+		si, err := c.NewScalar(new(big.Int).SetBytes(partialSig))
+		if len(partialSig) != 32 || err != nil {
+			return nil, &MuSig2InvalidContributionError{Signer: i, Contribution: "psig"}
+		}
+		sum = sum.add(si)
+	}
+
+	etacc := values.e.mul(values.keyAgg.tacc)
+	if !values.keyAgg.q.hasEvenY() {
+		etacc = etacc.neg()
+	}
+	sum = sum.add(etacc)
+
+	return &SchnorrSignature{r: values.R.x, s: sum}, nil
+}
+
+// cpoint parses a 33 bytes compressed point
+func cpoint(compressed []byte) (*Point, error) {
+	if len(compressed) != 33 || (compressed[0] != 0x02 && compressed[0] != 0x03) {
+		return nil, ErrInvalidXOnlyKey
+	}
+
+	P, err := liftX(new(big.Int).SetBytes(compressed[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	if compressed[0] == 0x03 {
+		return P.negate(), nil
+	}
+	return P, nil
+}
+
+// cpointExt is cpoint where 33 zero bytes stand for the identity point
+func cpointExt(compressed []byte) (*Point, error) {
+	if bytes.Equal(compressed, make([]byte, 33)) {
+		return S256Point(nil, nil), nil
+	}
+	return cpoint(compressed)
+}
+
+func cbytesExt(p *Point) []byte {
+	if p.x == nil {
+		return make([]byte, 33)
+	}
+	_, sec := p.SEC(true)
+	return sec
+}
@@ -0,0 +1,446 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+)
+
+// bip327Error is the error object of the BIP327 vectors in testdata/bip327
+
+type bip327Error struct {
+	Type    string `json:"type"`
+	Signer  *int   `json:"signer"`
+	Contrib string `json:"contrib"`
+}
+
+// hexBytes decodes the hex strings of the vectors
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	*h = b
+	return err
+}
+
+func loadBIP327Vectors(t *testing.T, name string, v any) {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/bip327/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pickBytes(all []hexBytes, indices []int) [][]byte {
+	picked := make([][]byte, len(indices))
+	for i, index := range indices {
+		picked[i] = all[index]
+	}
+	return picked
+}
+
+// checkBIP327Error checks that err is the error the vector expects, an
+// invalid_contribution has to blame the same signer for the same contribution
+func checkBIP327Error(t *testing.T, name string, err error, want bip327Error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("%s: no error, want %s", name, want.Type)
+	}
+
+	if want.Type != "invalid_contribution" {
+		return
+	}
+
+	var contribErr *MuSig2InvalidContributionError
+	if !errors.As(err, &contribErr) {
+		t.Fatalf("%s: %v, want an invalid contribution", name, err)
+	}
+
+	signer := MuSig2AggregatorIndex
+	if want.Signer != nil {
+		signer = *want.Signer
+	}
+	if contribErr.Signer != signer || (want.Contrib != "" && contribErr.Contribution != want.Contrib) {
+		t.Fatalf("%s: %v, want invalid %s from signer %d", name, err, want.Contrib, signer)
+	}
+}
+
+func TestMuSig2KeySortVectors(t *testing.T) {
+	var v struct {
+		PubKeys       []hexBytes `json:"pubkeys"`
+		SortedPubKeys []hexBytes `json:"sorted_pubkeys"`
+	}
+	loadBIP327Vectors(t, "key_sort_vectors.json", &v)
+
+	all := make([]int, len(v.PubKeys))
+	for i := range all {
+		all[i] = i
+	}
+
+	for i, key := range MuSig2KeySort(pickBytes(v.PubKeys, all)) {
+		if !bytes.Equal(key, v.SortedPubKeys[i]) {
+			t.Fatalf("key %d: %X, want %X", i, key, v.SortedPubKeys[i])
+		}
+	}
+}
+
+func TestMuSig2KeyAggVectors(t *testing.T) {
+	var v struct {
+		PubKeys []hexBytes `json:"pubkeys"`
+		Tweaks  []hexBytes `json:"tweaks"`
+		Valid   []struct {
+			KeyIndices []int    `json:"key_indices"`
+			Expected   hexBytes `json:"expected"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			KeyIndices   []int       `json:"key_indices"`
+			TweakIndices []int       `json:"tweak_indices"`
+			IsXOnly      []bool      `json:"is_xonly"`
+			Error        bip327Error `json:"error"`
+			Comment      string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadBIP327Vectors(t, "key_agg_vectors.json", &v)
+
+	for i, c := range v.Valid {
+		ctx, err := MuSig2KeyAgg(pickBytes(v.PubKeys, c.KeyIndices))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if got := ctx.AggregatedKey().XOnly(); !bytes.Equal(got, c.Expected) {
+			t.Errorf("valid %d: %X, want %X", i, got, c.Expected)
+		}
+	}
+
+	for _, c := range v.Errors {
+		ctx, err := MuSig2KeyAgg(pickBytes(v.PubKeys, c.KeyIndices))
+		for j, index := range c.TweakIndices {
+			if err != nil {
+				break
+			}
+			ctx, err = ctx.ApplyTweak(v.Tweaks[index], c.IsXOnly[j])
+		}
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+}
+
+func TestMuSig2NonceGenVectors(t *testing.T) {
+	var v struct {
+		Cases []struct {
+			Rand     hexBytes  `json:"rand_"`
+			Sk       *hexBytes `json:"sk"`
+			Pk       *hexBytes `json:"pk"`
+			AggPk    *hexBytes `json:"aggpk"`
+			Msg      *hexBytes `json:"msg"`
+			ExtraIn  *hexBytes `json:"extra_in"`
+			Expected hexBytes  `json:"expected"`
+		} `json:"test_cases"`
+	}
+	loadBIP327Vectors(t, "nonce_gen_vectors.json", &v)
+
+	// an absent value (null) is not the same as an empty one ("")
+	optional := func(h *hexBytes) []byte {
+		if h == nil {
+			return nil
+		}
+		return append([]byte{}, *h...)
+	}
+
+	for i, c := range v.Cases {
+		var sk *PrivateKey
+		if c.Sk != nil {
+			sk = MustPrivateKey(new(big.Int).SetBytes(*c.Sk))
+		}
+
+		secNonce, _, err := MuSig2NonceGen(c.Rand, sk, optional(c.Pk), optional(c.AggPk), optional(c.Msg), optional(c.ExtraIn))
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !bytes.Equal(secNonce, c.Expected) {
+			t.Errorf("case %d: %X, want %X", i, secNonce, c.Expected)
+		}
+	}
+}
+
+func TestMuSig2NonceAggVectors(t *testing.T) {
+	var v struct {
+		PubNonces []hexBytes `json:"pnonces"`
+		Valid     []struct {
+			Indices  []int    `json:"pnonce_indices"`
+			Expected hexBytes `json:"expected"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			Indices []int       `json:"pnonce_indices"`
+			Error   bip327Error `json:"error"`
+			Comment string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadBIP327Vectors(t, "nonce_agg_vectors.json", &v)
+
+	for i, c := range v.Valid {
+		aggNonce, err := MuSig2NonceAgg(pickBytes(v.PubNonces, c.Indices))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(aggNonce, c.Expected) {
+			t.Errorf("valid %d: %X, want %X", i, aggNonce, c.Expected)
+		}
+	}
+
+	for _, c := range v.Errors {
+		_, err := MuSig2NonceAgg(pickBytes(v.PubNonces, c.Indices))
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+}
+
+func TestMuSig2SignVerifyVectors(t *testing.T) {
+	var v struct {
+		Sk        hexBytes   `json:"sk"`
+		PubKeys   []hexBytes `json:"pubkeys"`
+		SecNonces []hexBytes `json:"secnonces"`
+		PubNonces []hexBytes `json:"pnonces"`
+		AggNonces []hexBytes `json:"aggnonces"`
+		Msgs      []hexBytes `json:"msgs"`
+		Valid     []struct {
+			KeyIndices    []int    `json:"key_indices"`
+			NonceIndices  []int    `json:"nonce_indices"`
+			AggNonceIndex int      `json:"aggnonce_index"`
+			MsgIndex      int      `json:"msg_index"`
+			SignerIndex   int      `json:"signer_index"`
+			Expected      hexBytes `json:"expected"`
+		} `json:"valid_test_cases"`
+		SignErrors []struct {
+			KeyIndices    []int       `json:"key_indices"`
+			AggNonceIndex int         `json:"aggnonce_index"`
+			MsgIndex      int         `json:"msg_index"`
+			SecNonceIndex int         `json:"secnonce_index"`
+			Error         bip327Error `json:"error"`
+			Comment       string      `json:"comment"`
+		} `json:"sign_error_test_cases"`
+		VerifyFail []struct {
+			Sig          hexBytes `json:"sig"`
+			KeyIndices   []int    `json:"key_indices"`
+			NonceIndices []int    `json:"nonce_indices"`
+			MsgIndex     int      `json:"msg_index"`
+			SignerIndex  int      `json:"signer_index"`
+			Comment      string   `json:"comment"`
+		} `json:"verify_fail_test_cases"`
+		VerifyErrors []struct {
+			Sig          hexBytes    `json:"sig"`
+			KeyIndices   []int       `json:"key_indices"`
+			NonceIndices []int       `json:"nonce_indices"`
+			MsgIndex     int         `json:"msg_index"`
+			SignerIndex  int         `json:"signer_index"`
+			Error        bip327Error `json:"error"`
+			Comment      string      `json:"comment"`
+		} `json:"verify_error_test_cases"`
+	}
+	loadBIP327Vectors(t, "sign_verify_vectors.json", &v)
+	sk := MustPrivateKey(new(big.Int).SetBytes(v.Sk))
+
+	for i, c := range v.Valid {
+		pubKeys := pickBytes(v.PubKeys, c.KeyIndices)
+		pubNonces := pickBytes(v.PubNonces, c.NonceIndices)
+		msg := v.Msgs[c.MsgIndex]
+
+		aggNonce, err := MuSig2NonceAgg(pubNonces)
+		if err != nil || !bytes.Equal(aggNonce, v.AggNonces[c.AggNonceIndex]) {
+			t.Fatalf("valid %d: aggregated nonce %X (%v), want %X", i, aggNonce, err, v.AggNonces[c.AggNonceIndex])
+		}
+
+		// Sign clears the secret nonce, every case gets a copy
+		secNonce := append([]byte{}, v.SecNonces[0]...)
+		partialSig, err := NewMuSig2Session(aggNonce, pubKeys, nil, nil, msg).Sign(secNonce, sk)
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(partialSig, c.Expected) {
+			t.Errorf("valid %d: %X, want %X", i, partialSig, c.Expected)
+		}
+
+		ok, err := MuSig2PartialSigVerify(partialSig, pubNonces, pubKeys, nil, nil, msg, c.SignerIndex)
+		if !ok || err != nil {
+			t.Errorf("valid %d: partial signature does not verify (%v)", i, err)
+		}
+	}
+
+	for _, c := range v.SignErrors {
+		session := NewMuSig2Session(v.AggNonces[c.AggNonceIndex], pickBytes(v.PubKeys, c.KeyIndices), nil, nil, v.Msgs[c.MsgIndex])
+		_, err := session.Sign(append([]byte{}, v.SecNonces[c.SecNonceIndex]...), sk)
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+
+	for _, c := range v.VerifyFail {
+		ok, err := MuSig2PartialSigVerify(c.Sig, pickBytes(v.PubNonces, c.NonceIndices), pickBytes(v.PubKeys, c.KeyIndices), nil, nil, v.Msgs[c.MsgIndex], c.SignerIndex)
+		if ok || err != nil {
+			t.Errorf("%s: verify = %v, %v, want false without error", c.Comment, ok, err)
+		}
+	}
+
+	for _, c := range v.VerifyErrors {
+		_, err := MuSig2PartialSigVerify(c.Sig, pickBytes(v.PubNonces, c.NonceIndices), pickBytes(v.PubKeys, c.KeyIndices), nil, nil, v.Msgs[c.MsgIndex], c.SignerIndex)
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+}
+
+func TestMuSig2TweakVectors(t *testing.T) {
+	var v struct {
+		Sk        hexBytes   `json:"sk"`
+		PubKeys   []hexBytes `json:"pubkeys"`
+		SecNonce  hexBytes   `json:"secnonce"`
+		PubNonces []hexBytes `json:"pnonces"`
+		AggNonce  hexBytes   `json:"aggnonce"`
+		Tweaks    []hexBytes `json:"tweaks"`
+		Msg       hexBytes   `json:"msg"`
+		Valid     []struct {
+			KeyIndices   []int    `json:"key_indices"`
+			NonceIndices []int    `json:"nonce_indices"`
+			TweakIndices []int    `json:"tweak_indices"`
+			IsXOnly      []bool   `json:"is_xonly"`
+			SignerIndex  int      `json:"signer_index"`
+			Expected     hexBytes `json:"expected"`
+			Comment      string   `json:"comment"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			KeyIndices   []int       `json:"key_indices"`
+			NonceIndices []int       `json:"nonce_indices"`
+			TweakIndices []int       `json:"tweak_indices"`
+			IsXOnly      []bool      `json:"is_xonly"`
+			SignerIndex  int         `json:"signer_index"`
+			Error        bip327Error `json:"error"`
+			Comment      string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadBIP327Vectors(t, "tweak_vectors.json", &v)
+	sk := MustPrivateKey(new(big.Int).SetBytes(v.Sk))
+
+	for _, c := range v.Valid {
+		pubKeys := pickBytes(v.PubKeys, c.KeyIndices)
+		tweaks := pickBytes(v.Tweaks, c.TweakIndices)
+
+		session := NewMuSig2Session(v.AggNonce, pubKeys, tweaks, c.IsXOnly, v.Msg)
+		partialSig, err := session.Sign(append([]byte{}, v.SecNonce...), sk)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Comment, err)
+		}
+		if !bytes.Equal(partialSig, c.Expected) {
+			t.Errorf("%s: %X, want %X", c.Comment, partialSig, c.Expected)
+		}
+
+		ok, err := MuSig2PartialSigVerify(partialSig, pickBytes(v.PubNonces, c.NonceIndices), pubKeys, tweaks, c.IsXOnly, v.Msg, c.SignerIndex)
+		if !ok || err != nil {
+			t.Errorf("%s: partial signature does not verify (%v)", c.Comment, err)
+		}
+	}
+
+	for _, c := range v.Errors {
+		session := NewMuSig2Session(v.AggNonce, pickBytes(v.PubKeys, c.KeyIndices), pickBytes(v.Tweaks, c.TweakIndices), c.IsXOnly, v.Msg)
+		_, err := session.Sign(append([]byte{}, v.SecNonce...), sk)
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+}
+
+func TestMuSig2SigAggVectors(t *testing.T) {
+	type sigAggCase struct {
+		AggNonce     hexBytes    `json:"aggnonce"`
+		NonceIndices []int       `json:"nonce_indices"`
+		KeyIndices   []int       `json:"key_indices"`
+		TweakIndices []int       `json:"tweak_indices"`
+		IsXOnly      []bool      `json:"is_xonly"`
+		PsigIndices  []int       `json:"psig_indices"`
+		Expected     hexBytes    `json:"expected"`
+		Error        bip327Error `json:"error"`
+		Comment      string      `json:"comment"`
+	}
+	var v struct {
+		PubKeys     []hexBytes   `json:"pubkeys"`
+		PubNonces   []hexBytes   `json:"pnonces"`
+		Tweaks      []hexBytes   `json:"tweaks"`
+		PartialSigs []hexBytes   `json:"psigs"`
+		Msg         hexBytes     `json:"msg"`
+		Valid       []sigAggCase `json:"valid_test_cases"`
+		Errors      []sigAggCase `json:"error_test_cases"`
+	}
+	loadBIP327Vectors(t, "sig_agg_vectors.json", &v)
+
+	for i, c := range v.Valid {
+		pubKeys := pickBytes(v.PubKeys, c.KeyIndices)
+		tweaks := pickBytes(v.Tweaks, c.TweakIndices)
+
+		aggNonce, err := MuSig2NonceAgg(pickBytes(v.PubNonces, c.NonceIndices))
+		if err != nil || !bytes.Equal(aggNonce, c.AggNonce) {
+			t.Fatalf("valid %d: aggregated nonce %X (%v), want %X", i, aggNonce, err, c.AggNonce)
+		}
+
+		sig, err := NewMuSig2Session(aggNonce, pubKeys, tweaks, c.IsXOnly, v.Msg).PartialSigAgg(pickBytes(v.PartialSigs, c.PsigIndices))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(sig.Serialize(), c.Expected) {
+			t.Errorf("valid %d: %X, want %X", i, sig.Serialize(), c.Expected)
+		}
+
+		// the result is a plain BIP340 signature of the tweaked aggregated key
+		ctx, err := MuSig2KeyAgg(pubKeys)
+		for j, tweak := range tweaks {
+			if err != nil {
+				break
+			}
+			ctx, err = ctx.ApplyTweak(tweak, c.IsXOnly[j])
+		}
+		if err != nil || !ctx.AggregatedKey().VerifySchnorr(v.Msg, sig) {
+			t.Errorf("valid %d: final signature does not verify (%v)", i, err)
+		}
+	}
+
+	for _, c := range v.Errors {
+		session := NewMuSig2Session(c.AggNonce, pickBytes(v.PubKeys, c.KeyIndices), pickBytes(v.Tweaks, c.TweakIndices), c.IsXOnly, v.Msg)
+		_, err := session.PartialSigAgg(pickBytes(v.PartialSigs, c.PsigIndices))
+		checkBIP327Error(t, c.Comment, err, c.Error)
+	}
+}
+
+func TestMuSig2PartialSigVerifyBounds(t *testing.T) {
+	pubKeys := [][]byte{}
+	pubNonces := [][]byte{}
+	for _, secret := range []int64{3, 5} {
+		_, pubKey := MustPrivateKey(big.NewInt(secret)).Public().SEC(true)
+		_, pubNonce, err := MuSig2NonceGen(make([]byte, 32), nil, pubKey, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+		pubNonces = append(pubNonces, pubNonce)
+	}
+	sig := make([]byte, 32)
+
+	for _, i := range []int{-1, 2} {
+		if _, err := MuSig2PartialSigVerify(sig, pubNonces, pubKeys, nil, nil, nil, i); err != ErrMuSig2SignerIndex {
+			t.Errorf("signer %d: %v, want ErrMuSig2SignerIndex", i, err)
+		}
+	}
+
+	if _, err := MuSig2PartialSigVerify(sig, pubNonces[:1], pubKeys, nil, nil, nil, 1); err != ErrMuSig2NonceCount {
+		t.Errorf("one nonce for two keys: %v, want ErrMuSig2NonceCount", err)
+	}
+
+	aggNonce, _ := MuSig2NonceAgg(pubNonces)
+	_, unknown := MustPrivateKey(big.NewInt(7)).Public().SEC(true)
+	session := NewMuSig2Session(aggNonce, pubKeys, nil, nil, nil)
+	if _, err := session.PartialSigVerify(sig, pubNonces[0], unknown); err != ErrMuSig2PubKeyNotInKeys {
+		t.Errorf("unknown key: %v, want ErrMuSig2PubKeyNotInKeys", err)
+	}
+}
@@ -1,6 +1,9 @@
 package ecc
 
 import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 )
@@ -16,13 +19,23 @@ const (
 )
 
 type Point struct {
-	a *FieldElement
-	b *FieldElement
-	x *FieldElement
-	y *FieldElement
+	curve *Curve
+	a     *FieldElement
+	b     *FieldElement
+	x     *FieldElement
+	y     *FieldElement
 }
 
-func OpOnField(x *FieldElement, y *FieldElement, scalar *big.Int, opType OP_TYPE) *FieldElement {
+// Errors returned by OpOnField and the point constructors and operations
+var (
+	ErrNotOnCurve      = errors.New("point is not on the curve")
+	ErrDifferentCurves = errors.New("points are not on the same curve")
+	ErrMissingOperand  = errors.New("operation is missing its field element or scalar operand")
+	ErrUnsupportedOp   = errors.New("operation type not supported")
+	ErrPartialIdentity = errors.New("x and y must both be nil for the identity point")
+)
+
+func OpOnField(x *FieldElement, y *FieldElement, scalar *big.Int, opType OP_TYPE) (*FieldElement, error) {
 	switch opType {
 	case ADD:
 		return x.Add(y)
@@ -33,19 +46,28 @@ func OpOnField(x *FieldElement, y *FieldElement, scalar *big.Int, opType OP_TYPE
 			return x.Multiply(y)
 		}
 		if scalar != nil {
-			return x.ScalarMul(scalar)
+			return x.ScalarMul(scalar), nil
 		}
-		panic("Error on multiply")
+		return nil, ErrMissingOperand
 	case DIV:
 		return x.Divide(y)
 	case EXP:
 		if scalar == nil {
-			panic("Scalar can't be nil on EXP operation")
+			return nil, ErrMissingOperand
 		}
-		return x.Power(scalar)
+		return x.Power(scalar), nil
 	default:
-		panic("Operation type not supported!")
+		return nil, ErrUnsupportedOp
+	}
+}
+
+// MustOpOnField is OpOnField that panics on error
+func MustOpOnField(x *FieldElement, y *FieldElement, scalar *big.Int, opType OP_TYPE) *FieldElement {
+	res, err := OpOnField(x, y, scalar, opType)
+	if err != nil {
+		panic(err)
 	}
+	return res
 }
 
 // y^2 = x^3 + ax + b
@@ -54,56 +76,87 @@ func NewEllipticCurvePoint(
 	y *FieldElement,
 	a *FieldElement,
 	b *FieldElement,
-) *Point {
+) (*Point, error) {
+	if a.checkOrder(b) != nil {
+		return nil, ErrMismatchedField
+	}
+
 	// x = nil and y = nil => Identity point
 	if x == nil && y == nil {
 		return &Point{
-			a: a,
-			b: b,
-			x: x,
-			y: y,
-		}
+			curve: knownCurve(a, b),
+			a:     a,
+			b:     b,
+			x:     x,
+			y:     y,
+		}, nil
 	}
 
-	left := OpOnField(y, nil, big.NewInt(2), EXP)
-	x3 := OpOnField(x, nil, big.NewInt(3), EXP)
-	ax := OpOnField(a, x, nil, MUL)
-	right := OpOnField(OpOnField(x3, ax, nil, ADD), b, nil, ADD)
+	if x == nil || y == nil {
+		return nil, ErrPartialIdentity
+	}
 
-	if left.EqualTo(right) != true {
-		err := fmt.Sprintf("Point(%v, %v) is not on the curve with a:%v, b:%v\n", x, y, a, b)
-		panic(err)
+	if x.checkOrder(a) != nil || y.checkOrder(a) != nil {
+		return nil, ErrMismatchedField
+	}
+
+	left := y.Power(big.NewInt(2))
+	right := x.Power(big.NewInt(3)).add(a.mul(x)).add(b)
+
+	if !left.EqualTo(right) {
+		return nil, ErrNotOnCurve
 	}
 
 	return &Point{
-		a: a,
-		b: b,
-		x: x,
-		y: y,
+		curve: knownCurve(a, b),
+		a:     a,
+		b:     b,
+		x:     x,
+		y:     y,
+	}, nil
+}
+
+// knownCurve returns secp256k1 when a and b are its parameters, points built by
+// hand on it can then still Verify, otherwise nil (the caller sets the curve)
+func knownCurve(a *FieldElement, b *FieldElement) *Curve {
+	c := Secp256k1()
+	if a.order.Cmp(c.p) == 0 && a.num.Cmp(c.a) == 0 && b.num.Cmp(c.b) == 0 {
+		return c
 	}
+	return nil
+}
+
+// MustEllipticCurvePoint is NewEllipticCurvePoint that panics on error
+func MustEllipticCurvePoint(x *FieldElement, y *FieldElement, a *FieldElement, b *FieldElement) *Point {
+	p, err := NewEllipticCurvePoint(x, y, a, b)
+	if err != nil {
+		panic(err)
+	}
+	return p
 }
 
 func S256Point(x *big.Int, y *big.Int) *Point {
-	a := S256Field(big.NewInt(0))
-	b := S256Field(big.NewInt(7))
+	c := Secp256k1()
 
 	if x == nil && y == nil {
-		return &Point{
-			a: a,
-			b: b,
-			x: nil,
-			y: nil,
-		}
+		return c.Identity()
 	}
 
 	return &Point{
-		a: a,
-		b: b,
-		x: S256Field(x),
-		y: S256Field(y),
+		curve: c,
+		a:     c.aField(),
+		b:     c.bField(),
+		x:     S256Field(x),
+		y:     S256Field(y),
 	}
 }
 
+// Curve is the curve the point was built from, nil for points made with
+// NewEllipticCurvePoint on parameters other than secp256k1
+func (p *Point) Curve() *Curve {
+	return p.curve
+}
+
 func (p *Point) Address(compressed bool, testnet bool) string {
 	hash160 := p.hash160(compressed)
 	prefix := []byte{}
@@ -123,132 +176,258 @@ func (p *Point) hash160(compressed bool) []byte {
 }
 
 // uncompressed = 0x04 + x (32 bytes) + y (32 bytes)
+// x and y take as many bytes as p, that is 32 bytes on secp256k1 and P-256
 func (p *Point) SEC(compressed bool) (string, []byte) {
+	size := (p.a.order.BitLen() + 7) / 8
 	secBytes := []byte{}
 	if !compressed {
 		secBytes = append(secBytes, 0x04)
-		secBytes = append(secBytes, p.x.num.Bytes()...)
-		secBytes = append(secBytes, p.y.num.Bytes()...)
+		secBytes = append(secBytes, p.x.num.FillBytes(make([]byte, size))...)
+		secBytes = append(secBytes, p.y.num.FillBytes(make([]byte, size))...)
 
-		return fmt.Sprintf("04%064x%064x", p.x.num, p.y.num), secBytes
+		return hex.EncodeToString(secBytes), secBytes
 	}
 	if new(big.Int).Mod(p.y.num, big.NewInt(2)).Cmp(big.NewInt(0)) == 0 {
 		secBytes = append(secBytes, 0x02)
-		secBytes = append(secBytes, p.x.num.Bytes()...)
-		return fmt.Sprintf("02%064x", p.x.num), secBytes
 	} else {
 		secBytes = append(secBytes, 0x03)
-		secBytes = append(secBytes, p.x.num.Bytes()...)
-		return fmt.Sprintf("03%064x", p.x.num), secBytes
 	}
+	secBytes = append(secBytes, p.x.num.FillBytes(make([]byte, size))...)
+	return hex.EncodeToString(secBytes), secBytes
 }
 
-func (p *Point) Verify(e *FieldElement, sig *Signature) bool {
+func (p *Point) Verify(e *Scalar, sig *Signature) bool {
 	/*
 		e = hash(message)
 		u1 = e * s^-1
 		u2 = r * s^-1
 		P = u1*G + u2*Q(current Point) => (xP, yP)
 		r = xP -> Verify success
+		n and G come from the curve of the point, a point without a curve (made
+		with NewEllipticCurvePoint on other parameters) cannot verify anything
 	*/
-	sInverse := sig.s.Inverse()
+	c := p.curve
+	if c == nil || p.x == nil {
+		return false
+	}
+
+	n := c.n
+	if sig.r.num.Sign() <= 0 || sig.r.num.Cmp(n) >= 0 || sig.s.num.Sign() <= 0 || sig.s.num.Cmp(n) >= 0 {
+		return false
+	}
+
+	sInverse := c.scalar(sig.s.num).inv()
 
-	u1 := OpOnField(e, sInverse, nil, MUL)
-	u2 := OpOnField(sig.r, sInverse, nil, MUL)
-	G := GeneratorPoint()
-	total := (G.ScalarMul(u1.num)).Add(p.ScalarMul(u2.num))
+	u1 := c.scalar(e.num).mul(sInverse)
+	u2 := c.scalar(sig.r.num).mul(sInverse)
+	total, err := MultiScalarMul([]*big.Int{u1.num, u2.num}, []*Point{c.g, p})
 
-	return total.x.num.Cmp(sig.r.num) == 0
+	if err != nil || total.x == nil {
+		return false
+	}
+
+	return new(big.Int).Mod(total.x.num, n).Cmp(sig.r.num) == 0
 }
 
 /*
 k*G, k = 13, => 13G
 k = 1101 (2^3 + 2^2 + 2^0) * G => 2^3G + 2^2G + 2^0G
-=> (G << 3) + (G << 2) + (G << 0)
+=> (((G * 2 + G) * 2) * 2 + G)
 1 trillition, 40 bits in binary form
-we at most do 40 times of addition => 1 trilliion times
+we at most do 40 doublings and 40 additions => 1 trilliion times
+all of them run in Jacobian coordinates, only the result is converted back
 */
 
 func (p *Point) ScalarMul(scalar *big.Int) *Point {
+	return p.scalarMulJacobian(scalar).toAffine()
+}
+
+func (p *Point) scalarMulJacobian(scalar *big.Int) *jacobianPoint {
 	if scalar == nil {
 		panic("Scalar can't be nil")
 	}
 
-	binaryForm := fmt.Sprintf("%b", scalar)
-	result := NewEllipticCurvePoint(nil, nil, p.a, p.b)
-	current := p
+	/*
+		Bit(i) reads the two's complement bits of a negative scalar while BitLen
+		is the size of |k|, so -k * P is computed as |k| * (-P). It is not reduced
+		mod n, NewCurve checks the order of G with n * G
+	*/
+	current := p.toJacobian()
+	if scalar.Sign() < 0 {
+		current = p.negate().toJacobian()
+		scalar = new(big.Int).Neg(scalar)
+	}
+	result := newJacobianIdentity(p.curve, p.a, p.b)
 
-	for i := len(binaryForm) - 1; i >= 0; i-- {
-		if binaryForm[i] == '1' {
-			result = result.Add(current)
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		result = result.double()
+		if scalar.Bit(i) == 1 {
+			result = result.add(current)
 		}
-		// doubling step 2G -> 4G -> 8G
-		current = current.Add(current)
 	}
 
 	return result
 }
 
-func (p *Point) Add(other *Point) *Point {
-	// Check if two points are on the same curve, a and b are constants so if the a and b of 2 point is different, its the two different curve. => Can't perform add operation
-	if !p.a.EqualTo(other.a) || !p.b.EqualTo(other.b) {
-		panic("Given two point not on the same curve")
+/*
+ScalarMul above only adds when a bit is set and stops at the highest set bit, so
+the time it takes tells something about the scalar. It is fine for public
+scalars (verification) but not for private keys and nonces, those go through
+ScalarMulConstantTime which is a Montgomery ladder:
+
+	R0 = P, R1 = 2P
+	for every bit b below the top bit:
+		b = 0 => R1 = R0 + R1, R0 = 2R0
+		b = 1 => R0 = R0 + R1, R1 = 2R1
+
+The two branches are the same operation with R0 and R1 swapped, so we always
+do one addition and one doubling and swap with a mask instead of an if.
+
+To make the number of steps independent of the scalar we use k + n or k + 2n
+(both give the same point because nG = 0), whichever has exactly 257 bits,
+the scalar is read from fixed-width 64-bit limbs instead of its binary string.
+On other curves n is replaced by h * n (every point has an order dividing it)
+and 257 by its bit length + 1. A point without a curve has no known order, the
+ladder then runs over the bits of the scalar itself.
+
+Only the ladder on secp256k1 is constant time: its points run on the fixed
+width s256Element field (jacobian256.go) where no operation branches on the
+values. The point formulas still special-case the identity and R0 = -R1, the
+ladder only meets them when the bits read so far are a multiple of n or
+(n - 1) / 2, which no scalar drawn at random gets to.
+Other curves use jacobianPoint on math/big, whose running time depends on the
+numbers and whose add branches on u1 = u2, so there the ladder only hides the
+bits of the scalar from the sequence of operations, not from the timing of
+each of them.
+*/
+func (p *Point) ScalarMulConstantTime(scalar *big.Int) *Point {
+	if scalar == nil {
+		panic("Scalar can't be nil")
 	}
 
 	if p.x == nil {
-		return other
+		return p
 	}
 
-	if other.x == nil {
-		return p
+	var order *big.Int
+	if p.curve != nil {
+		order = new(big.Int).Mul(p.curve.n, p.curve.h)
 	}
 
-	// points are on the vertical A(x, y), B(x, -y)
-	zero := NewFieldElement(p.y.order, big.NewInt(0))
-	if p.x.EqualTo(other.x) && OpOnField(p.y, other.y, nil, ADD).EqualTo(zero) {
-		return &Point{
-			x: nil,
-			y: nil,
-			a: p.a,
-			b: p.b,
+	limbs, bits := ladderScalar(scalar, order)
+	if bits == 0 {
+		return newJacobianIdentity(p.curve, p.a, p.b).toAffine()
+	}
+
+	r0 := p.toJacobian()
+	r1 := r0.double()
+
+	for i := bits - 2; i >= 0; i-- {
+		bit := int(limbs[i/64]>>(i%64)) & 1
+		r0, r1 = conditionalSwap(r0, r1, bit)
+		r1 = r0.add(r1)
+		r0 = r0.double()
+		r0, r1 = conditionalSwap(r0, r1, bit)
+	}
+
+	return r0.toAffine()
+}
+
+// ladderScalar returns k + n or k + 2n, the one with the top bit (bit n.BitLen())
+// set, as little endian limbs together with the number of bits to walk. A nil n
+// gives the bits of the scalar (the scalar must not be negative then).
+func ladderScalar(scalar *big.Int, n *big.Int) ([]uint64, int) {
+	var k *big.Int
+	bits := 0
+	if n == nil {
+		k = scalar
+		bits = scalar.BitLen()
+	} else {
+		k = new(big.Int).Mod(scalar, n)
+		bits = n.BitLen() + 1
+	}
+
+	size := (bits+63)/64*8 + 8
+	kn := make([]byte, size)
+	if n == nil {
+		k.FillBytes(kn)
+	} else {
+		new(big.Int).Add(k, n).FillBytes(kn)
+		k2n := new(big.Int).Add(k, new(big.Int).Lsh(n, 1)).FillBytes(make([]byte, size))
+
+		// keep k + n when its top bit is set, otherwise take k + 2n
+		top := bits - 1
+		topBit := int(kn[size-1-top/8]>>(top%8)) & 1
+		subtle.ConstantTimeCopy(1-topBit, kn, k2n)
+	}
+
+	limbs := make([]uint64, size/8)
+	for i := range limbs {
+		for j := 0; j < 8; j++ {
+			limbs[i] |= uint64(kn[size-1-i*8-j]) << (8 * j)
 		}
 	}
 
-	slope := p.SlopeTo(other)
-	slopeSquared := OpOnField(slope, nil, big.NewInt(2), EXP)
+	return limbs, bits
+}
 
-	// x3 = m^2 - x1 - x2
-	x3 := OpOnField(OpOnField(slopeSquared, p.x, nil, SUB), other.x, nil, SUB)
-	// y3 = m(x3 - x1) + y1
-	y3 := OpOnField(OpOnField(slope, OpOnField(x3, p.x, nil, SUB), nil, MUL), p.y, nil, ADD)
-	minusY3 := OpOnField(y3, nil, big.NewInt(-1), MUL)
+func (p *Point) Add(other *Point) (*Point, error) {
+	// Check if two points are on the same curve, a and b are constants so if the a and b of 2 point is different, its the two different curve. => Can't perform add operation
+	if !p.a.EqualTo(other.a) || !p.b.EqualTo(other.b) {
+		return nil, ErrDifferentCurves
+	}
 
-	return &Point{
-		x: x3,
-		y: minusY3,
-		a: p.a,
-		b: p.b,
+	if p.x == nil {
+		return other, nil
 	}
+
+	if other.x == nil {
+		return p, nil
+	}
+
+	return p.toJacobian().add(other.toJacobian()).toAffine(), nil
 }
 
-func (p *Point) SlopeTo(other *Point) *FieldElement {
+// MustAdd is Add that panics when the points are on different curves
+func (p *Point) MustAdd(other *Point) *Point {
+	sum, err := p.Add(other)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+// negate returns -P = (x, -y)
+func (p *Point) negate() *Point {
+	if p.x == nil {
+		return p
+	}
+
+	return &Point{curve: p.curve, a: p.a, b: p.b, x: p.x, y: p.y.Negate()}
+}
+
+func (p *Point) SlopeTo(other *Point) (*FieldElement, error) {
 	var numerator *FieldElement
 	var denominator *FieldElement
 
+	if !p.a.EqualTo(other.a) || !p.b.EqualTo(other.b) {
+		return nil, ErrDifferentCurves
+	}
+
+	if p.x == nil || other.x == nil {
+		return nil, ErrMissingOperand
+	}
+
 	if p.Equal(other) {
-		numerator = OpOnField(
-			OpOnField(OpOnField(p.x, nil, big.NewInt(2), EXP), nil, big.NewInt(3), MUL),
-			p.a,
-			nil,
-			ADD,
-		)
-		denominator = OpOnField(p.y, nil, big.NewInt(2), MUL)
+		numerator = p.x.Power(big.NewInt(2)).ScalarMul(big.NewInt(3)).add(p.a)
+		denominator = p.y.ScalarMul(big.NewInt(2))
 	} else {
-		numerator = OpOnField(other.y, p.y, nil, SUB)
-		denominator = OpOnField(other.x, p.x, nil, SUB)
+		numerator = other.y.sub(p.y)
+		denominator = other.x.sub(p.x)
 	}
 
-	return OpOnField(numerator, denominator, nil, DIV)
+	// vertical line (P + (-P) or doubling a point with y = 0)
+	return numerator.Divide(denominator)
 }
 
 func (p *Point) String() string {
@@ -273,6 +452,11 @@ func (p *Point) String() string {
 }
 
 func (p *Point) Equal(other *Point) bool {
+	// identity points have no coordinates to compare
+	if p.x == nil || other.x == nil {
+		return p.x == nil && other.x == nil && p.a.EqualTo(other.a) && p.b.EqualTo(other.b)
+	}
+
 	return p.a.EqualTo(other.a) && p.b.EqualTo(other.b) && p.x.EqualTo(other.x) &&
 		p.y.EqualTo(other.y)
 }
@@ -0,0 +1,254 @@
+package ecc
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+/*
+The affine formulas Point used before Jacobian coordinates, kept here to check
+the Jacobian results and to measure the speedup: every addition and doubling
+goes through SlopeTo, that is one Divide (a modular inverse) per step.
+*/
+func affineAdd(p *Point, other *Point) *Point {
+	if p.x == nil {
+		return other
+	}
+
+	if other.x == nil {
+		return p
+	}
+
+	// P + (-P) and doubling a point with y = 0 give the identity
+	slope, err := p.SlopeTo(other)
+	if err != nil || (p.x.EqualTo(other.x) && !p.y.EqualTo(other.y)) {
+		return &Point{curve: p.curve, a: p.a, b: p.b}
+	}
+
+	x3 := slope.Power(big.NewInt(2)).sub(p.x).sub(other.x)
+	y3 := slope.mul(p.x.sub(x3)).sub(p.y)
+
+	return &Point{curve: p.curve, a: p.a, b: p.b, x: x3, y: y3}
+}
+
+func affineScalarMul(p *Point, scalar *big.Int) *Point {
+	result := &Point{curve: p.curve, a: p.a, b: p.b}
+
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		result = affineAdd(result, result)
+		if scalar.Bit(i) == 1 {
+			result = affineAdd(result, p)
+		}
+	}
+
+	return result
+}
+
+func affineVerify(p *Point, e *Scalar, sig *Signature) bool {
+	c := p.curve
+	sInverse := sig.s.inv()
+	u1 := e.mul(sInverse)
+	u2 := sig.r.mul(sInverse)
+
+	total := affineAdd(affineScalarMul(c.g, u1.num), affineScalarMul(p, u2.num))
+	if total.x == nil {
+		return false
+	}
+
+	return c.scalar(total.x.num).Equal(sig.r)
+}
+
+func randomScalar(rng *rand.Rand, n *big.Int) *big.Int {
+	return new(big.Int).Rand(rng, n)
+}
+
+func TestJacobianMatchesAffine(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// secp256k1 runs on the limb field, P-256 on the generic math/big path
+	for _, c := range []*Curve{Secp256k1(), P256()} {
+		G := c.Generator()
+
+		for i := 0; i < 10; i++ {
+			k := randomScalar(rng, c.n)
+			want := affineScalarMul(G, k)
+
+			if got := G.ScalarMul(k); !got.Equal(want) {
+				t.Fatalf("%s: ScalarMul(%x) = %s, want %s", c.Name(), k, got, want)
+			}
+			if got := G.ScalarMulConstantTime(k); !got.Equal(want) {
+				t.Fatalf("%s: ScalarMulConstantTime(%x) = %s, want %s", c.Name(), k, got, want)
+			}
+			if got := c.ScalarBaseMul(k); !got.Equal(want) {
+				t.Fatalf("%s: ScalarBaseMul(%x) = %s, want %s", c.Name(), k, got, want)
+			}
+
+			P := want
+			Q := affineScalarMul(G, randomScalar(rng, c.n))
+			cases := []struct {
+				name string
+				p, q *Point
+			}{
+				{"P + Q", P, Q},
+				{"P + P", P, P},
+				{"P + -P", P, P.negate()},
+				{"P + 0", P, c.Identity()},
+				{"0 + Q", c.Identity(), Q},
+			}
+			for _, tc := range cases {
+				got, err := tc.p.Add(tc.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := affineAdd(tc.p, tc.q); !got.Equal(want) {
+					t.Fatalf("%s: %s = %s, want %s", c.Name(), tc.name, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestScalarMulNegativeAndLarge(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for _, c := range []*Curve{Secp256k1(), P256()} {
+		G := c.Generator()
+		P := G.ScalarMul(randomScalar(rng, c.n))
+		k := randomScalar(rng, c.n)
+
+		scalars := []*big.Int{
+			big.NewInt(-5),
+			big.NewInt(-1),
+			new(big.Int).Neg(k),
+			new(big.Int).Neg(c.n),
+			new(big.Int).Sub(big.NewInt(-1), c.n),
+			c.n,
+			new(big.Int).Add(c.n, big.NewInt(5)),
+			new(big.Int).Add(k, new(big.Int).Lsh(c.n, 3)),
+		}
+		for _, k := range scalars {
+			for _, Q := range []*Point{G, P} {
+				want := Q.ScalarMulConstantTime(k)
+				if got := Q.ScalarMul(k); !got.Equal(want) {
+					t.Errorf("%s: ScalarMul(%d) = %s, want %s", c.Name(), k, got, want)
+				}
+			}
+			if got := c.ScalarBaseMul(k); !got.Equal(G.ScalarMulConstantTime(k)) {
+				t.Errorf("%s: ScalarBaseMul(%d) = %s", c.Name(), k, got)
+			}
+		}
+
+		// -5G is 5G mirrored
+		if got := G.ScalarMul(big.NewInt(-5)); !got.Equal(G.ScalarMul(big.NewInt(5)).negate()) {
+			t.Errorf("%s: -5G = %s", c.Name(), got)
+		}
+	}
+}
+
+func BenchmarkScalarMul(b *testing.B) {
+	G := GeneratorPoint()
+	k := randomScalar(rand.New(rand.NewSource(1)), BitcoinN())
+
+	b.Run("jacobian", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			G.ScalarMul(k)
+		}
+	})
+
+	b.Run("affine", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			affineScalarMul(G, k)
+		}
+	})
+}
+
+func BenchmarkAdd(b *testing.B) {
+	G := GeneratorPoint()
+	P := G.ScalarMul(big.NewInt(3))
+	Q := G.ScalarMul(big.NewInt(7))
+
+	b.Run("jacobian", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			P.MustAdd(Q)
+		}
+	})
+
+	b.Run("affine", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			affineAdd(P, Q)
+		}
+	})
+}
+
+func BenchmarkVerify(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	pk := MustPrivateKey(randomScalar(rng, BitcoinN()))
+	e := Secp256k1().scalar(randomScalar(rng, BitcoinN()))
+	sig := pk.MustSign(e)
+	Q := pk.Public()
+
+	b.Run("jacobian", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Q.Verify(e, sig)
+		}
+	})
+
+	b.Run("affine", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			affineVerify(Q, e, sig)
+		}
+	})
+}
+
+func TestNewEllipticCurvePointErrors(t *testing.T) {
+	// y^2 = x^3 + 7 over F_223
+	prime := big.NewInt(223)
+	fe := func(num int64) *FieldElement { return MustFieldElement(prime, big.NewInt(num)) }
+	a, b := fe(0), fe(7)
+	otherB := MustFieldElement(big.NewInt(227), big.NewInt(7))
+
+	cases := []struct {
+		name string
+		x, y *FieldElement
+		a, b *FieldElement
+		want error
+	}{
+		{"(47, 71)", fe(47), fe(71), a, b, nil},
+		{"identity", nil, nil, a, b, nil},
+		{"(200, 119) is not on the curve", fe(200), fe(119), a, b, ErrNotOnCurve},
+		{"(42, 99) is not on the curve", fe(42), fe(99), a, b, ErrNotOnCurve},
+		{"nil x", nil, fe(71), a, b, ErrPartialIdentity},
+		{"nil y", fe(47), nil, a, b, ErrPartialIdentity},
+		{"a and b in different fields", fe(47), fe(71), a, otherB, ErrMismatchedField},
+		{"x in another field", MustFieldElement(big.NewInt(227), big.NewInt(47)), fe(71), a, b, ErrMismatchedField},
+	}
+
+	for _, c := range cases {
+		_, err := NewEllipticCurvePoint(c.x, c.y, c.a, c.b)
+		if err != c.want {
+			t.Errorf("NewEllipticCurvePoint %s: %v, want %v", c.name, err, c.want)
+		}
+		if c.want != nil {
+			mustPanic(t, "MustEllipticCurvePoint "+c.name, c.want, func() {
+				MustEllipticCurvePoint(c.x, c.y, c.a, c.b)
+			})
+		}
+	}
+}
+
+func TestPointAddDifferentCurves(t *testing.T) {
+	G := Secp256k1().Generator()
+	other := P256().Generator()
+
+	if _, err := G.Add(other); err != ErrDifferentCurves {
+		t.Errorf("secp256k1 + P-256: %v, want ErrDifferentCurves", err)
+	}
+	mustPanic(t, "MustAdd secp256k1 + P-256", ErrDifferentCurves, func() {
+		G.MustAdd(other)
+	})
+
+	if sum := G.MustAdd(G); !sum.Equal(G.ScalarMul(big.NewInt(2))) {
+		t.Errorf("MustAdd G + G = %s, want 2G", sum)
+	}
+}
@@ -1,71 +1,151 @@
 package ecc
 
 import (
-	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
 )
 
+var (
+	ErrPrivateKeyOutOfRange = errors.New("private key must be between 1 and n - 1")
+	ErrNilMessageHash       = errors.New("message hash is nil")
+)
+
 type PrivateKey struct {
-	d *big.Int
+	d *Scalar
 	Q *Point
 }
 
-func NewPrivateKey(secret *big.Int) *PrivateKey {
-	G := GeneratorPoint()
+// NewPrivateKey returns the secp256k1 key of secret, keys of other curves are
+// made with Curve.NewPrivateKey
+func NewPrivateKey(secret *big.Int) (*PrivateKey, error) {
+	return Secp256k1().NewPrivateKey(secret)
+}
+
+// MustPrivateKey is NewPrivateKey that panics when secret is out of range
+func MustPrivateKey(secret *big.Int) *PrivateKey {
+	pk, err := NewPrivateKey(secret)
+	if err != nil {
+		panic(err)
+	}
+	return pk
+}
 
+// newPrivateKey skips the range check, secret must already be in 1 to n - 1 of secp256k1
+func newPrivateKey(secret *big.Int) *PrivateKey {
 	return &PrivateKey{
-		d: secret,
-		Q: G.ScalarMul(secret),
+		d: Secp256k1().scalar(secret),
+		Q: baseMul(secret),
+	}
+}
+
+// Sign signs the message hash e, build it with Curve.ScalarFromHash so it is
+// reduced the same way Point.Verify expects
+func (pk *PrivateKey) Sign(e *Scalar) (*Signature, error) {
+	return pk.SignWithEntropy(e, nil)
+}
+
+// MustSign is Sign that panics on error
+func (pk *PrivateKey) MustSign(e *Scalar) *Signature {
+	sig, err := pk.Sign(e)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+// SignWithEntropy works like Sign but mixes extraEntropy into the RFC 6979
+// nonce derivation, with nil extraEntropy it gives the same signature as Sign
+func (pk *PrivateKey) SignWithEntropy(e *Scalar, extraEntropy []byte) (*Signature, error) {
+	sig, _, err := pk.sign(e, extraEntropy)
+	return sig, err
+}
+
+// check tells whether pk was built by NewPrivateKey (and not a zero PrivateKey{})
+func (pk *PrivateKey) check() error {
+	if pk.d == nil || pk.Q == nil || pk.Q.curve == nil || pk.d.IsZero() || pk.d.n.Cmp(pk.Q.curve.n) != 0 {
+		return ErrPrivateKeyOutOfRange
 	}
+	return nil
 }
 
-func (pk *PrivateKey) Sign(e *big.Int) *Signature {
+// checkSecp256k1 is check for the Bitcoin only signatures (Schnorr, taproot, compact)
+func (pk *PrivateKey) checkSecp256k1() error {
+	if err := pk.check(); err != nil {
+		return err
+	}
+	if pk.Q.curve != Secp256k1() {
+		return ErrUnsupportedCurve
+	}
+	return nil
+}
+
+func (pk *PrivateKey) Curve() *Curve {
+	return pk.Q.curve
+}
+
+// sign also returns the recovery id of the signature, see RecoverPublicKey
+func (pk *PrivateKey) sign(e *Scalar, extraEntropy []byte) (*Signature, byte, error) {
 	/*
 				All calculation on finite field element
-				choose k randomly from 1 -> n-1
+				derive k deterministically from d and e (RFC 6979), 1 -> n-1
 				R = kG
 				r = xR; if r = 0; choose different k
 				s = k^-1 x (e (hashed message) + d (private key) x r); if s = 0; choose different k
 		    Signature{r, s}
 	*/
-	n := BitcoinN()
-	G := GeneratorPoint()
+	if err := pk.check(); err != nil {
+		return nil, 0, err
+	}
+
+	if e == nil {
+		return nil, 0, ErrNilMessageHash
+	}
+
+	if err := pk.d.checkOrder(e); err != nil {
+		return nil, 0, err
+	}
+
+	curve := pk.Q.curve
+	n := curve.n
+	nonce := newRFC6979Nonce(n, pk.d.num, e.num, extraEntropy)
 
 	for {
-		k, err := rand.Int(rand.Reader, n)
-		if err != nil {
-			panic("Error occur while generating private key")
-		}
+		k := nonce.next()
 
-		r := G.ScalarMul(k).x.num
-		if r.Cmp(big.NewInt(0)) == 0 {
+		R := curve.baseMul(k)
+		r := curve.scalar(R.x.num)
+		if r.IsZero() {
 			continue
 		}
 
-		kField := NewFieldElement(n, k)
-		eField := NewFieldElement(n, e)
-		rField := NewFieldElement(n, r)
-		dField := NewFieldElement(n, pk.d)
-		kInverse := kField.Inverse()
-		dxr := dField.Multiply(rField)
-		ePlusDxr := eField.Add(dxr)
-		s := kInverse.Multiply(ePlusDxr)
+		// recovery id: bit 0 is the parity of R.y, bit 1 tells that R.x was >= n
+		recID := byte(R.y.num.Bit(0))
+		if R.x.num.Cmp(n) >= 0 {
+			recID |= 2
+		}
+
+		kInverse := curve.scalar(k).inv()
+		dxr := pk.d.mul(r)
+		ePlusDxr := e.add(dxr)
+		s := kInverse.mul(ePlusDxr)
 
-		if s.num.Cmp(big.NewInt(0)) == 0 {
+		if s.IsZero() {
 			continue
 		}
 		/*
 		   if s > n / 2 we need to change it to n - s, when doing signature verify, s and n - s are equivalance doing this change is for malleability reason
 		*/
-		if s.num.Cmp(new(big.Int).Div(n, big.NewInt(2))) > 0 {
-			s = NewFieldElement(n, new(big.Int).Sub(n, s.num))
+		if s.IsHigh() {
+			s = s.neg()
+			// n - s is the signature of -R, flip the parity
+			recID ^= 1
 		}
 
 		return &Signature{
-			r: rField,
+			r: r,
 			s: s,
-		}
+		}, recID, nil
 	}
 }
 
@@ -74,5 +154,5 @@ func (pk *PrivateKey) Public() *Point {
 }
 
 func (pk *PrivateKey) String() string {
-	return fmt.Sprintf("Private key hex:{%s}", pk.d)
+	return fmt.Sprintf("Private key hex:{%s}", pk.d.num)
 }
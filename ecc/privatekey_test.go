@@ -0,0 +1,63 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNewPrivateKeyRange(t *testing.T) {
+	n := Secp256k1().N()
+
+	cases := []struct {
+		name   string
+		secret *big.Int
+		want   error
+	}{
+		{"1", big.NewInt(1), nil},
+		{"n - 1", new(big.Int).Sub(n, big.NewInt(1)), nil},
+		{"nil", nil, ErrPrivateKeyOutOfRange},
+		{"0", big.NewInt(0), ErrPrivateKeyOutOfRange},
+		{"-1", big.NewInt(-1), ErrPrivateKeyOutOfRange},
+		{"n", n, ErrPrivateKeyOutOfRange},
+	}
+
+	for _, c := range cases {
+		if _, err := NewPrivateKey(c.secret); err != c.want {
+			t.Errorf("NewPrivateKey(%s): %v, want %v", c.name, err, c.want)
+		}
+		if c.want != nil {
+			mustPanic(t, "MustPrivateKey("+c.name+")", c.want, func() {
+				MustPrivateKey(c.secret)
+			})
+		}
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	e := Secp256k1().ScalarFromHash(Hash256("message"))
+
+	cases := []struct {
+		name string
+		pk   *PrivateKey
+		e    *Scalar
+		want error
+	}{
+		{"zero value PrivateKey", &PrivateKey{}, e, ErrPrivateKeyOutOfRange},
+		{"nil message hash", MustPrivateKey(big.NewInt(1)), nil, ErrNilMessageHash},
+		{"P-256 message hash", MustPrivateKey(big.NewInt(1)), P256().ScalarFromHash(Hash256("message")), ErrMismatchedScalar},
+	}
+
+	for _, c := range cases {
+		if _, err := c.pk.Sign(c.e); err != c.want {
+			t.Errorf("Sign %s: %v, want %v", c.name, err, c.want)
+		}
+		mustPanic(t, "MustSign "+c.name, c.want, func() {
+			c.pk.MustSign(c.e)
+		})
+	}
+
+	pk := MustPrivateKey(big.NewInt(1))
+	if !pk.Public().Verify(e, pk.MustSign(e)) {
+		t.Error("MustSign signature does not verify")
+	}
+}
@@ -0,0 +1,149 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+/*
+Public key recovery
+s = k^-1 (e + d*r) => d*G = r^-1 (s*R - e*G), so with R we can get Q = d*G back.
+The signature only has r = R.x mod n, that leaves four candidates for R:
+	x = r or r + n (only when r + n < p), and an even or odd y
+The recovery id (0 -> 3) tells which one was used when signing:
+	bit 0 = parity of R.y, bit 1 = R.x was r + n
+
+Compact signature (65 bytes) = header || r (32 bytes) || s (32 bytes)
+header = 27 + recovery id, + 4 when the public key is compressed
+*/
+
+var (
+	ErrInvalidRecoveryID    = errors.New("recovery id must be between 0 and 3")
+	ErrRecoveryFailed       = errors.New("no public key can be recovered from the signature")
+	ErrCompactSigLength     = errors.New("compact signature must be 65 bytes")
+	ErrCompactSigHeader     = errors.New("compact signature header must be between 27 and 34")
+	ErrCompactSigOutOfRange = errors.New("compact signature r and s must be between 1 and n-1")
+)
+
+const compactSigHeader = 27
+
+// RecoverPublicKey returns the public key that made sig for the message hash e
+func RecoverPublicKey(e *Scalar, sig *Signature, recID byte) (*Point, error) {
+	if recID > 3 {
+		return nil, ErrInvalidRecoveryID
+	}
+
+	if e == nil {
+		return nil, ErrNilMessageHash
+	}
+
+	c := Secp256k1()
+	if e.n.Cmp(c.n) != 0 {
+		return nil, ErrMismatchedScalar
+	}
+
+	x := new(big.Int).Set(sig.r.num)
+	if recID&2 != 0 {
+		x.Add(x, c.n)
+	}
+
+	R, err := liftX(x)
+	if err != nil {
+		return nil, ErrRecoveryFailed
+	}
+
+	if byte(R.y.num.Bit(0)) != recID&1 {
+		R = R.negate()
+	}
+
+	// Q = (s * r^-1) * R + (-e * r^-1) * G
+	rInverse := c.scalar(sig.r.num).inv()
+	u1 := e.neg().mul(rInverse)
+	u2 := c.scalar(sig.s.num).mul(rInverse)
+
+	Q := multiScalarMulJacobian([]*big.Int{u2.num, u1.num}, []*Point{R, GeneratorPoint()}).toAffine()
+	if Q.x == nil {
+		return nil, ErrRecoveryFailed
+	}
+
+	return Q, nil
+}
+
+// RecoverPublicKeys returns every candidate public key, indexed by recovery
+// id, nil where that recovery id gives no key
+func RecoverPublicKeys(e *Scalar, sig *Signature) []*Point {
+	candidates := make([]*Point, 4)
+	for recID := byte(0); recID < 4; recID++ {
+		Q, err := RecoverPublicKey(e, sig, recID)
+		if err == nil {
+			candidates[recID] = Q
+		}
+	}
+	return candidates
+}
+
+// SignCompact signs the message hash e and returns the 65 bytes compact
+// signature, compressed tells which SEC format the public key is used with
+func (pk *PrivateKey) SignCompact(e *Scalar, compressed bool) ([]byte, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return nil, err
+	}
+
+	sig, recID, err := pk.sign(e, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Compact(recID, compressed), nil
+}
+
+func (s *Signature) Compact(recID byte, compressed bool) []byte {
+	header := compactSigHeader + recID
+	if compressed {
+		header += 4
+	}
+
+	buf := make([]byte, 65)
+	buf[0] = header
+	s.r.num.FillBytes(buf[1:33])
+	s.s.num.FillBytes(buf[33:65])
+	return buf
+}
+
+func ParseCompact(compact []byte) (sig *Signature, recID byte, compressed bool, err error) {
+	if len(compact) != 65 {
+		return nil, 0, false, ErrCompactSigLength
+	}
+
+	header := compact[0]
+	if header < compactSigHeader || header > compactSigHeader+7 {
+		return nil, 0, false, ErrCompactSigHeader
+	}
+
+	recID = (header - compactSigHeader) & 3
+	compressed = header-compactSigHeader >= 4
+
+	c := Secp256k1()
+	r, errR := c.NewScalar(new(big.Int).SetBytes(compact[1:33]))
+	s, errS := c.NewScalar(new(big.Int).SetBytes(compact[33:65]))
+	if errR != nil || errS != nil || r.IsZero() || s.IsZero() {
+		return nil, 0, false, ErrCompactSigOutOfRange
+	}
+
+	return NewSignature(r, s), recID, compressed, nil
+}
+
+// RecoverCompact recovers the public key from a compact signature and tells
+// if the key was used compressed
+func RecoverCompact(e *Scalar, compact []byte) (*Point, bool, error) {
+	sig, recID, compressed, err := ParseCompact(compact)
+	if err != nil {
+		return nil, false, err
+	}
+
+	Q, err := RecoverPublicKey(e, sig, recID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return Q, compressed, nil
+}
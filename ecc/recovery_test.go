@@ -0,0 +1,142 @@
+package ecc
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestSignCompactRecover(t *testing.T) {
+	c := Secp256k1()
+	seen := map[byte]bool{}
+
+	for i := int64(1); i <= 20; i++ {
+		pk := MustPrivateKey(big.NewInt(i * 7919))
+		hash := sha256.Sum256(big.NewInt(i).Bytes())
+		e := c.ScalarFromHash(hash[:])
+
+		for _, compressed := range []bool{true, false} {
+			compact, err := pk.SignCompact(e, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sig, recID, gotCompressed, err := ParseCompact(compact)
+			if err != nil || gotCompressed != compressed {
+				t.Fatalf("ParseCompact(%x) = %v, %v", compact, gotCompressed, err)
+			}
+			seen[recID] = true
+
+			Q, err := RecoverPublicKey(e, sig, recID)
+			if err != nil || !Q.Equal(pk.Public()) {
+				t.Errorf("key %d: recovered %v (%v), want %s", i, Q, err, pk.Public())
+			}
+
+			Q, gotCompressed, err = RecoverCompact(e, compact)
+			if err != nil || !Q.Equal(pk.Public()) || gotCompressed != compressed {
+				t.Errorf("key %d: RecoverCompact = %v, %v, %v", i, Q, gotCompressed, err)
+			}
+
+			if candidates := RecoverPublicKeys(e, sig); !candidates[recID].Equal(pk.Public()) {
+				t.Errorf("key %d: candidate %d is %v", i, recID, candidates[recID])
+			}
+		}
+	}
+
+	if !seen[0] || !seen[1] {
+		t.Errorf("recovery ids seen: %v, want both parities", seen)
+	}
+}
+
+func TestRecoverPublicKeyHighX(t *testing.T) {
+	c := Secp256k1()
+
+	/*
+		bit 1 of the recovery id is set when R.x = r + n, signing only gets
+		there with a chance of 2^-128, so R is picked first: x = n + 2 is on the
+		curve, r = 2. Any s then makes a valid signature for the key
+		Q = r^-1 (s*R - e*G), which recovery has to find again.
+	*/
+	R, err := liftX(new(big.Int).Add(c.n, big.NewInt(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.scalar(big.NewInt(2))
+	s := c.scalar(big.NewInt(12345))
+	e := c.scalar(big.NewInt(67890))
+	sig := NewSignature(r, s)
+
+	sR := R.ScalarMul(s.num)
+	eG := c.ScalarBaseMul(e.num)
+	want := sR.MustAdd(eG.negate()).ScalarMul(r.inv().num)
+
+	// liftX gives the even y
+	Q, err := RecoverPublicKey(e, sig, 2)
+	if err != nil || !Q.Equal(want) {
+		t.Fatalf("recID 2: %v (%v), want %s", Q, err, want)
+	}
+	if !Q.Verify(e, sig) {
+		t.Error("signature does not verify under the recovered key")
+	}
+
+	// recovery id 3 takes -R, the low x candidates are other keys
+	if Q, err := RecoverPublicKey(e, sig, 3); err != nil || Q.Equal(want) || !Q.Verify(e, sig) {
+		t.Errorf("recID 3: %v, %v", Q, err)
+	}
+	if Q, err := RecoverPublicKey(e, sig, 0); err == nil && Q.Equal(want) {
+		t.Error("recID 0 gives the key of R.x = r + n")
+	}
+}
+
+func TestRecoverPublicKeyErrors(t *testing.T) {
+	c := Secp256k1()
+	sig := NewSignature(c.scalar(big.NewInt(1)), c.scalar(big.NewInt(1)))
+
+	if _, err := RecoverPublicKey(c.scalar(big.NewInt(1)), sig, 4); err != ErrInvalidRecoveryID {
+		t.Errorf("recID 4: %v, want ErrInvalidRecoveryID", err)
+	}
+	if _, err := RecoverPublicKey(nil, sig, 0); err != ErrNilMessageHash {
+		t.Errorf("nil hash: %v, want ErrNilMessageHash", err)
+	}
+	if _, err := RecoverPublicKey(P256().scalar(big.NewInt(1)), sig, 0); err != ErrMismatchedScalar {
+		t.Errorf("P-256 hash: %v, want ErrMismatchedScalar", err)
+	}
+}
+
+func TestParseCompactErrors(t *testing.T) {
+	n := BitcoinN()
+	compact := func(header byte, r *big.Int, s *big.Int) []byte {
+		buf := make([]byte, 65)
+		buf[0] = header
+		r.FillBytes(buf[1:33])
+		s.FillBytes(buf[33:65])
+		return buf
+	}
+	one := big.NewInt(1)
+
+	cases := []struct {
+		name    string
+		compact []byte
+		err     error
+	}{
+		{"too short", compact(27, one, one)[:64], ErrCompactSigLength},
+		{"too long", append(compact(27, one, one), 0x00), ErrCompactSigLength},
+		{"header 26", compact(26, one, one), ErrCompactSigHeader},
+		{"header 35", compact(35, one, one), ErrCompactSigHeader},
+		{"zero r", compact(27, big.NewInt(0), one), ErrCompactSigOutOfRange},
+		{"r = n", compact(27, n, one), ErrCompactSigOutOfRange},
+		{"zero s", compact(31, one, big.NewInt(0)), ErrCompactSigOutOfRange},
+		{"s = n", compact(31, one, n), ErrCompactSigOutOfRange},
+	}
+	for _, c := range cases {
+		if _, _, _, err := ParseCompact(c.compact); err != c.err {
+			t.Errorf("%s: %v, want %v", c.name, err, c.err)
+		}
+	}
+
+	// 27 + 4 + 3 is the last valid header
+	_, recID, compressed, err := ParseCompact(compact(34, one, one))
+	if err != nil || recID != 3 || !compressed {
+		t.Errorf("header 34: recID %d, compressed %v, %v", recID, compressed, err)
+	}
+}
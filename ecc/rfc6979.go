@@ -0,0 +1,104 @@
+package ecc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+/*
+RFC 6979 deterministic nonce generation (HMAC-SHA256)
+
+	h1 = hash(message), x = private key, q = order of the group (n)
+	V = 0x01 0x01 ... 0x01 (32 bytes)
+	K = 0x00 0x00 ... 0x00 (32 bytes)
+	K = HMAC_K(V || 0x00 || int2octets(x) || bits2octets(h1) || extra)
+	V = HMAC_K(V)
+	K = HMAC_K(V || 0x01 || int2octets(x) || bits2octets(h1) || extra)
+	V = HMAC_K(V)
+	loop:
+		V = HMAC_K(V), k = bits2int(V)
+		if 1 <= k < q -> use k
+		K = HMAC_K(V || 0x00), V = HMAC_K(V) and try again
+
+The same key and message always give the same k, so we don't depend on the
+quality of the random number generator when signing. extra is the optional
+additional data of section 3.6, it lets the caller mix some entropy in.
+*/
+type rfc6979Nonce struct {
+	q     *big.Int
+	k     []byte
+	v     []byte
+	first bool
+}
+
+func newRFC6979Nonce(q *big.Int, x *big.Int, h1 *big.Int, extra []byte) *rfc6979Nonce {
+	qLen := (q.BitLen() + 7) / 8
+	key := append(int2octets(x, qLen), bits2octets(h1, q, qLen)...)
+	key = append(key, extra...)
+
+	v := make([]byte, sha256.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+
+	k = hmacSum(k, v, []byte{0x00}, key)
+	v = hmacSum(k, v)
+	k = hmacSum(k, v, []byte{0x01}, key)
+	v = hmacSum(k, v)
+
+	return &rfc6979Nonce{q: q, k: k, v: v, first: true}
+}
+
+// next returns the next candidate nonce, the signer calls it again when the
+// previous k gives r = 0 or s = 0
+func (rn *rfc6979Nonce) next() *big.Int {
+	qLen := (rn.q.BitLen() + 7) / 8
+
+	for {
+		if !rn.first {
+			rn.k = hmacSum(rn.k, rn.v, []byte{0x00})
+			rn.v = hmacSum(rn.k, rn.v)
+		}
+		rn.first = false
+
+		t := []byte{}
+		for len(t) < qLen {
+			rn.v = hmacSum(rn.k, rn.v)
+			t = append(t, rn.v...)
+		}
+
+		k := bits2int(t, rn.q)
+		if k.Sign() > 0 && k.Cmp(rn.q) < 0 {
+			return k
+		}
+	}
+}
+
+func hmacSum(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// bits2int takes the leftmost qlen bits of the input
+func bits2int(b []byte, q *big.Int) *big.Int {
+	num := new(big.Int).SetBytes(b)
+	if shift := len(b)*8 - q.BitLen(); shift > 0 {
+		num.Rsh(num, uint(shift))
+	}
+	return num
+}
+
+// int2octets encodes num as a big endian byte array of exactly length bytes
+func int2octets(num *big.Int, length int) []byte {
+	return num.FillBytes(make([]byte, length))
+}
+
+// bits2octets reduces the message hash modulo q before encoding it
+func bits2octets(h *big.Int, q *big.Int, length int) []byte {
+	return int2octets(new(big.Int).Mod(h, q), length)
+}
@@ -0,0 +1,121 @@
+package ecc
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// RFC 6979 secp256k1 / SHA-256 vectors (bitcoinjs-lib fixtures), s is the low s one
+var rfc6979Vectors = []struct {
+	d   string
+	msg string
+	k   string
+	r   string
+	s   string
+}{
+	{
+		d:   "1",
+		msg: "Satoshi Nakamoto",
+		k:   "8f8a276c19f4149656b280621e358cce24f5f52542772691ee69063b74f15d15",
+		r:   "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8",
+		s:   "2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+	},
+	{
+		d:   "1",
+		msg: "All those moments will be lost in time, like tears in rain. Time to die...",
+		k:   "38aa22d72376b4dbc472e06c3ba403ee0a394da63fc58d88686c611aba98d6b3",
+		r:   "8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b",
+		s:   "547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+	},
+	{
+		d:   "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+		msg: "Satoshi Nakamoto",
+		k:   "33a19b60e25fb6f4435af53a3d42d493644827367e6453928554f43e49aa6f90",
+		r:   "fd567d121db66e382991534ada77a6bd3106f0a1098c231e47993447cd6af2d0",
+		s:   "6b39cd0eb1bc8603e159ef5c20a5c8ad685a45b06ce9bebed3f153d10d93bed5",
+	},
+	{
+		d:   "f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+		msg: "Alan Turing",
+		k:   "525a82b70e67874398067543fd84c83d30c175fdc45fdeee082fe13b1d7cfdf1",
+		r:   "7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c",
+		s:   "58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+	},
+}
+
+func TestRFC6979Vectors(t *testing.T) {
+	c := Secp256k1()
+
+	for _, v := range rfc6979Vectors {
+		d := hexInt(v.d)
+		hash := sha256.Sum256([]byte(v.msg))
+		e := c.ScalarFromHash(hash[:])
+
+		k := newRFC6979Nonce(c.n, d, e.num, nil).next()
+		if got := fmt.Sprintf("%064x", k); got != v.k {
+			t.Errorf("%s: k = %s, want %s", v.msg, got, v.k)
+		}
+
+		sig, err := MustPrivateKey(d).Sign(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := fmt.Sprintf("%064x", sig.R().Int()); got != v.r {
+			t.Errorf("%s: r = %s, want %s", v.msg, got, v.r)
+		}
+		if got := fmt.Sprintf("%064x", sig.S().Int()); got != v.s {
+			t.Errorf("%s: s = %s, want %s", v.msg, got, v.s)
+		}
+	}
+}
+
+func TestSignLowS(t *testing.T) {
+	c := Secp256k1()
+
+	// every vector above has k^-1 (e + d*r) > n / 2, Sign must return n - s
+	for _, v := range rfc6979Vectors {
+		d := hexInt(v.d)
+		hash := sha256.Sum256([]byte(v.msg))
+		e := c.ScalarFromHash(hash[:])
+		k := c.scalar(hexInt(v.k))
+		r := c.scalar(hexInt(v.r))
+
+		rawS := k.inv().mul(e.add(c.scalar(d).mul(r)))
+		if !rawS.IsHigh() {
+			t.Fatalf("%s: s before normalization is already low", v.msg)
+		}
+
+		sig := MustPrivateKey(d).MustSign(e)
+		if sig.S().IsHigh() || !sig.S().Equal(rawS.Negate()) {
+			t.Errorf("%s: s = %s, want n - %s", v.msg, sig.S(), rawS)
+		}
+
+		if !MustPrivateKey(d).Public().Verify(e, sig) {
+			t.Errorf("%s: signature does not verify", v.msg)
+		}
+	}
+}
+
+func TestSignWithEntropy(t *testing.T) {
+	c := Secp256k1()
+	pk := MustPrivateKey(big.NewInt(1))
+	hash := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	e := c.ScalarFromHash(hash[:])
+
+	plain, _ := pk.Sign(e)
+	same, _ := pk.SignWithEntropy(e, nil)
+	mixed, _ := pk.SignWithEntropy(e, make([]byte, 32))
+
+	if !plain.R().Equal(same.R()) || !plain.S().Equal(same.S()) {
+		t.Error("SignWithEntropy with nil entropy differs from Sign")
+	}
+	if plain.R().Equal(mixed.R()) {
+		t.Error("extra entropy did not change the nonce")
+	}
+	if !pk.Public().Verify(e, mixed) {
+		t.Error("signature with extra entropy does not verify")
+	}
+}
@@ -0,0 +1,143 @@
+package ecc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/*
+Scalar is an integer mod n, the order of the generator point of a curve.
+
+Private keys, nonces, r and s of signatures, message hashes and tweaks are
+scalars, x and y of points are FieldElements mod p. Both used to be
+FieldElements and nothing stopped a value mod p from being multiplied with a
+value mod n, a separate type makes the two moduli impossible to mix up.
+*/
+type Scalar struct {
+	n   *big.Int
+	num *big.Int
+}
+
+var ErrMismatchedScalar = errors.New("scalars do not have the same group order")
+
+// NewScalar returns num as a scalar of the curve, num must be in 0 to n - 1
+func (c *Curve) NewScalar(num *big.Int) (*Scalar, error) {
+	if num == nil || num.Sign() < 0 || num.Cmp(c.n) >= 0 {
+		return nil, ErrOutOfRange
+	}
+
+	return &Scalar{n: c.n, num: new(big.Int).Set(num)}, nil
+}
+
+// ScalarFromHash turns a message hash into a scalar, the hash is cut to the
+// bit length of n (bits2int of RFC 6979) and reduced mod n like ECDSA does
+func (c *Curve) ScalarFromHash(hash []byte) *Scalar {
+	return c.scalar(bits2int(hash, c.n))
+}
+
+// scalar reduces num mod n
+func (c *Curve) scalar(num *big.Int) *Scalar {
+	return &Scalar{n: c.n, num: new(big.Int).Mod(num, c.n)}
+}
+
+// PUBLIC METHODS
+
+func (s *Scalar) Add(other *Scalar) (*Scalar, error) {
+	if err := s.checkOrder(other); err != nil {
+		return nil, err
+	}
+	return s.add(other), nil
+}
+
+func (s *Scalar) Sub(other *Scalar) (*Scalar, error) {
+	if err := s.checkOrder(other); err != nil {
+		return nil, err
+	}
+	return s.sub(other), nil
+}
+
+func (s *Scalar) Mul(other *Scalar) (*Scalar, error) {
+	if err := s.checkOrder(other); err != nil {
+		return nil, err
+	}
+	return s.mul(other), nil
+}
+
+// Inverse returns s^-1, n is prime so every scalar but 0 has one
+func (s *Scalar) Inverse() (*Scalar, error) {
+	if s.IsZero() {
+		return nil, ErrDivisionByZero
+	}
+	return s.inv(), nil
+}
+
+// Negate returns n - s (0 stays 0)
+func (s *Scalar) Negate() *Scalar {
+	return s.neg()
+}
+
+func (s *Scalar) IsZero() bool {
+	return s.num.Sign() == 0
+}
+
+// IsHigh tells whether s > n / 2, low s signatures (BIP62 / BIP146) need it false
+func (s *Scalar) IsHigh() bool {
+	return s.num.Cmp(new(big.Int).Rsh(s.n, 1)) > 0
+}
+
+func (s *Scalar) Equal(other *Scalar) bool {
+	return s.num.Cmp(other.num) == 0 && s.n.Cmp(other.n) == 0
+}
+
+// Int returns a copy of the value
+func (s *Scalar) Int() *big.Int {
+	return new(big.Int).Set(s.num)
+}
+
+// Bytes encodes s big endian on as many bytes as n (32 bytes on secp256k1)
+func (s *Scalar) Bytes() []byte {
+	return s.num.FillBytes(make([]byte, (s.n.BitLen()+7)/8))
+}
+
+func (s *Scalar) String() string {
+	return fmt.Sprintf("Scalar{n: %s, num: %s}", s.n.String(), s.num.String())
+}
+
+// PRIVATE METHODS
+
+func (s *Scalar) checkOrder(other *Scalar) error {
+	if s.n.Cmp(other.n) != 0 {
+		return ErrMismatchedScalar
+	}
+	return nil
+}
+
+func (s *Scalar) add(other *Scalar) *Scalar {
+	num := new(big.Int).Add(s.num, other.num)
+	return &Scalar{n: s.n, num: num.Mod(num, s.n)}
+}
+
+func (s *Scalar) sub(other *Scalar) *Scalar {
+	num := new(big.Int).Sub(s.num, other.num)
+	return &Scalar{n: s.n, num: num.Mod(num, s.n)}
+}
+
+func (s *Scalar) mul(other *Scalar) *Scalar {
+	num := new(big.Int).Mul(s.num, other.num)
+	return &Scalar{n: s.n, num: num.Mod(num, s.n)}
+}
+
+// inv is s^-1 mod n, 0 gives 0
+func (s *Scalar) inv() *Scalar {
+	num := new(big.Int).ModInverse(s.num, s.n)
+	if num == nil {
+		num = new(big.Int)
+	}
+	return &Scalar{n: s.n, num: num}
+}
+
+func (s *Scalar) neg() *Scalar {
+	num := new(big.Int).Neg(s.num)
+	return &Scalar{n: s.n, num: num.Mod(num, s.n)}
+}
@@ -0,0 +1,191 @@
+package ecc
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+	"testing"
+)
+
+// toyCurve is y^2 = x^3 + 7 over F_10657, it has a prime number of points
+// (10567, 14 bits) so every hash is longer than n
+func toyCurve(t *testing.T) *Curve {
+	t.Helper()
+	c, err := NewCurve("toy", big.NewInt(10657), big.NewInt(0), big.NewInt(7), big.NewInt(1), big.NewInt(292), big.NewInt(10567), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewScalar(t *testing.T) {
+	c := Secp256k1()
+	n := c.N()
+
+	cases := []struct {
+		name string
+		num  *big.Int
+		want error
+	}{
+		{"0", big.NewInt(0), nil},
+		{"n - 1", new(big.Int).Sub(n, big.NewInt(1)), nil},
+		{"n", n, ErrOutOfRange},
+		{"n + 1", new(big.Int).Add(n, big.NewInt(1)), ErrOutOfRange},
+		{"-1", big.NewInt(-1), ErrOutOfRange},
+		{"nil", nil, ErrOutOfRange},
+	}
+
+	for _, v := range cases {
+		s, err := c.NewScalar(v.num)
+		if err != v.want {
+			t.Errorf("NewScalar(%s): %v, want %v", v.name, err, v.want)
+		}
+		if err == nil && s.Int().Cmp(v.num) != 0 {
+			t.Errorf("NewScalar(%s) = %s", v.name, s)
+		}
+	}
+
+	// the scalar keeps its own copy
+	num := big.NewInt(5)
+	s, _ := c.NewScalar(num)
+	num.SetInt64(6)
+	if s.Int().Int64() != 5 {
+		t.Errorf("NewScalar shares its big.Int: %s", s)
+	}
+}
+
+func TestScalarArithmetic(t *testing.T) {
+	for _, c := range []*Curve{Secp256k1(), P256(), toyCurve(t)} {
+		n := c.N()
+		one := c.scalar(big.NewInt(1))
+		zero := c.scalar(big.NewInt(0))
+
+		for _, num := range []*big.Int{big.NewInt(1), big.NewInt(2), new(big.Int).Rsh(n, 1), new(big.Int).Sub(n, big.NewInt(1))} {
+			s := c.scalar(num)
+
+			inv, err := s.Inverse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if product, _ := s.Mul(inv); !product.Equal(one) {
+				t.Errorf("%s: %s * %s = %s, want 1", c.Name(), s, inv, product)
+			}
+
+			neg := s.Negate()
+			if sum, _ := s.Add(neg); !sum.IsZero() {
+				t.Errorf("%s: %s + %s = %s, want 0", c.Name(), s, neg, sum)
+			}
+			if diff, _ := zero.Sub(s); !diff.Equal(neg) {
+				t.Errorf("%s: 0 - %s = %s, want %s", c.Name(), s, diff, neg)
+			}
+		}
+
+		if _, err := zero.Inverse(); err != ErrDivisionByZero {
+			t.Errorf("%s: Inverse of 0: %v, want ErrDivisionByZero", c.Name(), err)
+		}
+		if !zero.Negate().IsZero() {
+			t.Errorf("%s: -0 = %s, want 0", c.Name(), zero.Negate())
+		}
+
+		// n - 1 wraps around to 0
+		if sum, _ := c.scalar(new(big.Int).Sub(n, big.NewInt(1))).Add(one); !sum.IsZero() {
+			t.Errorf("%s: (n - 1) + 1 = %s, want 0", c.Name(), sum)
+		}
+
+		if size := len(c.scalar(big.NewInt(1)).Bytes()); size != (n.BitLen()+7)/8 {
+			t.Errorf("%s: Bytes is %d bytes long", c.Name(), size)
+		}
+	}
+}
+
+func TestScalarIsHigh(t *testing.T) {
+	for _, c := range []*Curve{Secp256k1(), P256(), toyCurve(t)} {
+		half := new(big.Int).Rsh(c.N(), 1)
+
+		cases := []struct {
+			num  *big.Int
+			high bool
+		}{
+			{big.NewInt(0), false},
+			{big.NewInt(1), false},
+			{half, false},
+			{new(big.Int).Add(half, big.NewInt(1)), true},
+			{new(big.Int).Sub(c.N(), big.NewInt(1)), true},
+		}
+
+		for _, v := range cases {
+			s := c.scalar(v.num)
+			if s.IsHigh() != v.high {
+				t.Errorf("%s: IsHigh(%s) = %v, want %v", c.Name(), v.num, s.IsHigh(), v.high)
+			}
+			// s and n - s are never both high
+			if !s.IsZero() && s.IsHigh() == s.Negate().IsHigh() {
+				t.Errorf("%s: %s and its negation are both high or both low", c.Name(), v.num)
+			}
+		}
+	}
+}
+
+func TestScalarFromHash(t *testing.T) {
+	sha := sha256.Sum256([]byte("abc"))
+	sha5 := sha512.Sum512([]byte("abc"))
+	ones := make([]byte, 32)
+	for i := range ones {
+		ones[i] = 0xff
+	}
+
+	cases := []struct {
+		name string
+		c    *Curve
+		hash []byte
+		want *big.Int
+	}{
+		// n is 256 bits, the hash is only reduced
+		{"P-256 sha256", P256(), sha[:], hexInt("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")},
+		{"P-256 all ones", P256(), ones, hexInt("ffffffff00000000000000004319055258e8617b0c46353d039cdaae")},
+		{"secp256k1 all ones", Secp256k1(), ones, hexInt("14551231950b75fc4402da1732fc9bebe")},
+		// a 512 bit hash keeps its leftmost 256 bits
+		{"P-256 sha512", P256(), sha5[:], hexInt("ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a")},
+		// the toy n is 14 bits, 0xba78 >> 2 = 11934 is then reduced mod 10567
+		{"toy sha256", toyCurve(t), sha[:], big.NewInt(1367)},
+		{"toy short hash", toyCurve(t), []byte{0x00, 0x04}, big.NewInt(1)},
+		{"toy empty hash", toyCurve(t), nil, big.NewInt(0)},
+	}
+
+	for _, v := range cases {
+		s := v.c.ScalarFromHash(v.hash)
+		if s.Int().Cmp(v.want) != 0 {
+			t.Errorf("%s: ScalarFromHash = %x, want %x", v.name, s.Int(), v.want)
+		}
+		if s.n.Cmp(v.c.n) != 0 {
+			t.Errorf("%s: ScalarFromHash has order %s", v.name, s.n)
+		}
+	}
+}
+
+func TestScalarMismatchedOrder(t *testing.T) {
+	a := Secp256k1().scalar(big.NewInt(5))
+	b := P256().scalar(big.NewInt(5))
+
+	ops := []struct {
+		name string
+		op   func(*Scalar) (*Scalar, error)
+	}{
+		{"Add", a.Add},
+		{"Sub", a.Sub},
+		{"Mul", a.Mul},
+	}
+
+	for _, op := range ops {
+		if _, err := op.op(b); err != ErrMismatchedScalar {
+			t.Errorf("%s across orders: %v, want ErrMismatchedScalar", op.name, err)
+		}
+		if _, err := op.op(a); err != nil {
+			t.Errorf("%s on the same order: %v", op.name, err)
+		}
+	}
+
+	if a.Equal(b) {
+		t.Error("5 mod the secp256k1 order equals 5 mod the P-256 order")
+	}
+}
@@ -0,0 +1,182 @@
+package ecc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+/*
+BIP340 Schnorr signatures
+
+Public keys are x-only (32 bytes), of the two points with that x we always
+take the one with an even y. A private key d whose point has an odd y is used
+as n - d so that its point is the even one.
+
+Sign (d private key, P = dG, m message, a 32 bytes of auxiliary randomness)
+	t = d xor hash_BIP0340/aux(a)
+	k = hash_BIP0340/nonce(t || x(P) || m) mod n, R = kG, k = n - k if R.y is odd
+	e = hash_BIP0340/challenge(x(R) || x(P) || m) mod n
+	signature = x(R) || (k + e*d) mod n
+Verify
+	R = s*G - e*P
+	valid if R is not the identity, R.y is even and R.x = r
+*/
+
+var (
+	ErrInvalidXOnlyKey     = errors.New("x-only public key is not the x coordinate of a point on the curve")
+	ErrSchnorrSigLength    = errors.New("schnorr signature must be 64 bytes")
+	ErrSchnorrSigRTooLarge = errors.New("schnorr signature r is not less than the field size")
+	ErrSchnorrSigSTooLarge = errors.New("schnorr signature s is not less than the group order")
+	ErrAuxRandLength       = errors.New("auxiliary randomness must be 32 bytes")
+	ErrSchnorrSignFailed   = errors.New("produced schnorr signature does not verify")
+)
+
+// r is the x of R (mod p), s is a scalar
+type SchnorrSignature struct {
+	r *FieldElement
+	s *Scalar
+}
+
+// Serialize returns x(R) (32 bytes) || s (32 bytes)
+func (sig *SchnorrSignature) Serialize() []byte {
+	buf := make([]byte, 64)
+	sig.r.num.FillBytes(buf[:32])
+	sig.s.num.FillBytes(buf[32:])
+	return buf
+}
+
+func (sig *SchnorrSignature) String() string {
+	return fmt.Sprintf("SchnorrSignature(r: {%s}, s: {%s})", sig.r.String(), sig.s.String())
+}
+
+func ParseSchnorrSignature(sig []byte) (*SchnorrSignature, error) {
+	if len(sig) != 64 {
+		return nil, ErrSchnorrSigLength
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	if r.Cmp(secp256k1P()) >= 0 {
+		return nil, ErrSchnorrSigRTooLarge
+	}
+
+	s, err := Secp256k1().NewScalar(new(big.Int).SetBytes(sig[32:]))
+	if err != nil {
+		return nil, ErrSchnorrSigSTooLarge
+	}
+
+	return &SchnorrSignature{r: S256Field(r), s: s}, nil
+}
+
+// XOnly returns the 32 bytes x coordinate used as BIP340 public key
+func (p *Point) XOnly() []byte {
+	return p.x.num.FillBytes(make([]byte, 32))
+}
+
+// ParseXOnly returns the point with the given x and an even y (lift_x in BIP340)
+func ParseXOnly(xOnly []byte) (*Point, error) {
+	if len(xOnly) != 32 {
+		return nil, ErrInvalidXOnlyKey
+	}
+
+	return liftX(new(big.Int).SetBytes(xOnly))
+}
+
+/*
+lift_x
+c = x^3 + 7, y = c^((p + 1) / 4)
+fail if x >= p or y^2 != c (x is not on the curve)
+y = p - y if y is odd
+*/
+func liftX(x *big.Int) (*Point, error) {
+	p := GeneratorPoint().a.order
+	if x.Cmp(p) >= 0 {
+		return nil, ErrInvalidXOnlyKey
+	}
+
+	c := S256Field(x).Power(big.NewInt(3)).add(S256Field(big.NewInt(7)))
+	y := c.sqrt()
+	if !y.mul(y).EqualTo(c) {
+		return nil, ErrInvalidXOnlyKey
+	}
+
+	if y.num.Bit(0) == 1 {
+		y = S256Field(new(big.Int).Sub(p, y.num))
+	}
+
+	return S256Point(new(big.Int).Set(x), y.num), nil
+}
+
+func (p *Point) hasEvenY() bool {
+	return p.y.num.Bit(0) == 0
+}
+
+func (pk *PrivateKey) SignSchnorr(msg []byte, auxRand []byte) (*SchnorrSignature, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return nil, err
+	}
+
+	if len(auxRand) != 32 {
+		return nil, ErrAuxRandLength
+	}
+
+	c := Secp256k1()
+	P := pk.Q
+	d := pk.d
+	if !P.hasEvenY() {
+		d = d.neg()
+	}
+
+	t := d.Bytes()
+	auxHash := TaggedHash("BIP0340/aux", auxRand)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	rand := TaggedHash("BIP0340/nonce", t, P.XOnly(), msg)
+	k := c.scalar(new(big.Int).SetBytes(rand))
+	if k.IsZero() {
+		return nil, ErrSchnorrSignFailed
+	}
+
+	R := baseMul(k.num)
+	if !R.hasEvenY() {
+		k = k.neg()
+	}
+
+	e := schnorrChallenge(R.XOnly(), P.XOnly(), msg)
+	s := e.mul(d).add(k)
+
+	sig := &SchnorrSignature{r: R.x, s: s}
+	// BIP340 recommends checking the signature before handing it out
+	if !P.VerifySchnorr(msg, sig) {
+		return nil, ErrSchnorrSignFailed
+	}
+
+	return sig, nil
+}
+
+// VerifySchnorr checks sig against the x-only key of p, the parity of p.y is ignored
+func (p *Point) VerifySchnorr(msg []byte, sig *SchnorrSignature) bool {
+	if p.x == nil || p.curve != Secp256k1() {
+		return false
+	}
+
+	P, err := liftX(p.x.num)
+	if err != nil {
+		return false
+	}
+
+	e := schnorrChallenge(sig.r.num.FillBytes(make([]byte, 32)), P.XOnly(), msg)
+
+	R := multiScalarMulJacobian([]*big.Int{sig.s.num, e.neg().num}, []*Point{GeneratorPoint(), P}).toAffine()
+	if R.x == nil || !R.hasEvenY() {
+		return false
+	}
+
+	return R.x.num.Cmp(sig.r.num) == 0
+}
+
+func schnorrChallenge(rx []byte, px []byte, msg []byte) *Scalar {
+	return Secp256k1().scalar(new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", rx, px, msg)))
+}
@@ -0,0 +1,99 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"testing"
+)
+
+type bip340Vector struct {
+	index     string
+	secretKey []byte
+	publicKey []byte
+	auxRand   []byte
+	msg       []byte
+	sig       []byte
+	valid     bool
+	comment   string
+}
+
+// loadBIP340Vectors reads test-vectors.csv of BIP340
+func loadBIP340Vectors(t *testing.T) []bip340Vector {
+	t.Helper()
+
+	f, err := os.Open("testdata/bip340_vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []bip340Vector{}
+	for _, record := range records[1:] {
+		fields := make([][]byte, 6)
+		for i := 1; i <= 5; i++ {
+			if fields[i], err = hex.DecodeString(record[i]); err != nil {
+				t.Fatalf("vector %s: %v", record[0], err)
+			}
+		}
+
+		vectors = append(vectors, bip340Vector{
+			index:     record[0],
+			secretKey: fields[1],
+			publicKey: fields[2],
+			auxRand:   fields[3],
+			msg:       fields[4],
+			sig:       fields[5],
+			valid:     record[6] == "TRUE",
+			comment:   record[7],
+		})
+	}
+
+	return vectors
+}
+
+func TestBIP340Vectors(t *testing.T) {
+	for _, v := range loadBIP340Vectors(t) {
+		if len(v.secretKey) > 0 {
+			pk := MustPrivateKey(new(big.Int).SetBytes(v.secretKey))
+			if !bytes.Equal(pk.Public().XOnly(), v.publicKey) {
+				t.Errorf("vector %s: public key %x, want %x", v.index, pk.Public().XOnly(), v.publicKey)
+			}
+
+			sig, err := pk.SignSchnorr(v.msg, v.auxRand)
+			if err != nil {
+				t.Fatalf("vector %s: %v", v.index, err)
+			}
+			if !bytes.Equal(sig.Serialize(), v.sig) {
+				t.Errorf("vector %s: signature %x, want %x", v.index, sig.Serialize(), v.sig)
+			}
+		}
+
+		if got := verifyBIP340Vector(v); got != v.valid {
+			t.Errorf("vector %s (%s): verify = %v, want %v", v.index, v.comment, got, v.valid)
+		}
+	}
+}
+
+// verifyBIP340Vector fails on keys and signatures that don't parse, like the
+// reference implementation
+func verifyBIP340Vector(v bip340Vector) bool {
+	P, err := ParseXOnly(v.publicKey)
+	if err != nil {
+		return false
+	}
+
+	sig, err := ParseSchnorrSignature(v.sig)
+	if err != nil {
+		return false
+	}
+
+	return P.VerifySchnorr(v.msg, sig)
+}
@@ -1,16 +1,41 @@
 package ecc
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Errors returned by ParseDER, one for each kind of BIP66 violation
+var (
+	ErrDERTooShort         = errors.New("der signature too short")
+	ErrDERTooLong          = errors.New("der signature too long")
+	ErrDERInvalidSequence  = errors.New("der signature does not start with a sequence marker")
+	ErrDERInvalidLength    = errors.New("der signature length does not match its content")
+	ErrDERInvalidIntMarker = errors.New("der signature integer marker is not 0x02")
+	ErrDERZeroLengthInt    = errors.New("der signature integer has zero length")
+	ErrDERNegativeInt      = errors.New("der signature integer is negative")
+	ErrDERPaddedInt        = errors.New("der signature integer has excessive padding")
+	ErrDERIntOutOfRange    = errors.New("der signature integer is not in the range of 1 to n-1")
+)
 
 type Signature struct {
-	r *FieldElement
-	s *FieldElement
+	r *Scalar
+	s *Scalar
 }
 
-func NewSignature(r *FieldElement, s *FieldElement) *Signature {
+func NewSignature(r *Scalar, s *Scalar) *Signature {
 	return &Signature{r, s}
 }
 
+func (s *Signature) R() *Scalar {
+	return s.r
+}
+
+func (s *Signature) S() *Scalar {
+	return s.s
+}
+
 /*
 1. Set the first byte to 0x30
 2. Second byte is the total length of s and r
@@ -20,16 +45,12 @@ func NewSignature(r *FieldElement, s *FieldElement) *Signature {
 6. Do the same for s as step 4
 total length of 0x44 or 0x45
 */
-func (s *Signature) DER() []byte {
+func (s *Signature) DER() ([]byte, error) {
 	rBytes := s.r.num.Bytes()
 	sBytes := s.s.num.Bytes()
 
-	if len(rBytes) == 0 {
-		panic("r is empty")
-	}
-
-	if len(sBytes) == 0 {
-		panic("s is empty")
+	if len(rBytes) == 0 || len(sBytes) == 0 {
+		return nil, ErrDERZeroLengthInt
 	}
 
 	if rBytes[0] >= byte(0x80) {
@@ -49,7 +70,111 @@ func (s *Signature) DER() []byte {
 	encoded = append(encoded, rBytes...)
 	encoded = append(encoded, sBytes...)
 
-	return encoded
+	return encoded, nil
+}
+
+// MustDER is DER that panics when r or s is zero
+func (s *Signature) MustDER() []byte {
+	der, err := s.DER()
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
+
+/*
+ParseDER is the reverse of DER, it follows the strict encoding rules of BIP66
+
+	0x30 [total-length] 0x02 [R-length] [R] 0x02 [S-length] [S]
+
+1. Total length is between 8 and 72 bytes and matches the second byte
+2. R and S are not empty and not negative (first bit not set)
+3. R and S have no unnecessary 0x00 padding at the beginning
+4. R and S are in the range of 1 to n-1
+*/
+func ParseDER(der []byte) (*Signature, error) {
+	if len(der) < 8 {
+		return nil, ErrDERTooShort
+	}
+
+	if len(der) > 72 {
+		return nil, ErrDERTooLong
+	}
+
+	if der[0] != 0x30 {
+		return nil, ErrDERInvalidSequence
+	}
+
+	if int(der[1]) != len(der)-2 {
+		return nil, ErrDERInvalidLength
+	}
+
+	rLen := int(der[3])
+	// the marker and length byte of S must follow R
+	if 5+rLen >= len(der) {
+		return nil, ErrDERInvalidLength
+	}
+
+	sLen := int(der[5+rLen])
+	if rLen+sLen+6 != len(der) {
+		return nil, ErrDERInvalidLength
+	}
+
+	r, err := parseDERInt(der[2 : 4+rLen])
+	if err != nil {
+		return nil, fmt.Errorf("r: %w", err)
+	}
+
+	sNum, err := parseDERInt(der[4+rLen:])
+	if err != nil {
+		return nil, fmt.Errorf("s: %w", err)
+	}
+
+	c := Secp256k1()
+	return NewSignature(c.scalar(r), c.scalar(sNum)), nil
+}
+
+// ParseSignatureWithHashType splits the trailing sighash byte off a signature
+// taken from a script and parses the DER part
+func ParseSignatureWithHashType(sig []byte) (*Signature, byte, error) {
+	if len(sig) == 0 {
+		return nil, 0, ErrDERTooShort
+	}
+
+	parsed, err := ParseDER(sig[:len(sig)-1])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return parsed, sig[len(sig)-1], nil
+}
+
+// parseDERInt parses 0x02 [length] [value]
+func parseDERInt(encoded []byte) (*big.Int, error) {
+	if encoded[0] != 0x02 {
+		return nil, ErrDERInvalidIntMarker
+	}
+
+	value := encoded[2:]
+	if len(value) == 0 {
+		return nil, ErrDERZeroLengthInt
+	}
+
+	if value[0]&0x80 != 0 {
+		return nil, ErrDERNegativeInt
+	}
+
+	// 0x00 is only allowed when the next byte has the first bit set
+	if len(value) > 1 && value[0] == 0x00 && value[1]&0x80 == 0 {
+		return nil, ErrDERPaddedInt
+	}
+
+	num := new(big.Int).SetBytes(value)
+	if num.Sign() == 0 || num.Cmp(BitcoinN()) >= 0 {
+		return nil, ErrDERIntOutOfRange
+	}
+
+	return num, nil
 }
 
 func (s *Signature) String() string {
@@ -0,0 +1,116 @@
+package ecc
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// derSig builds 0x30 [length] 0x02 [R-length] [R] 0x02 [S-length] [S] without checking anything
+func derSig(r []byte, s []byte) []byte {
+	der := []byte{0x30, byte(4 + len(r) + len(s)), 0x02, byte(len(r))}
+	der = append(der, r...)
+	der = append(der, 0x02, byte(len(s)))
+	return append(der, s...)
+}
+
+func TestDERRoundTrip(t *testing.T) {
+	c := Secp256k1()
+	n := c.n
+	values := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(0x7f),
+		big.NewInt(0x80),
+		big.NewInt(0xff),
+		hexInt("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0"),
+		hexInt("80000000000000000000000000000000000000000000000000000000000000ff"),
+		new(big.Int).Sub(n, big.NewInt(1)),
+	}
+
+	for _, r := range values {
+		for _, s := range values {
+			sig := NewSignature(c.scalar(r), c.scalar(s))
+			der, err := sig.DER()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a set high bit needs a 0x00 in front, the value is positive
+			if wantPad := r.BitLen()%8 == 0; wantPad != (der[4] == 0x00) {
+				t.Errorf("r %x: der %x has the wrong padding", r, der)
+			}
+
+			parsed, err := ParseDER(der)
+			if err != nil {
+				t.Fatalf("ParseDER(%x): %v", der, err)
+			}
+			if !parsed.R().Equal(sig.R()) || !parsed.S().Equal(sig.S()) {
+				t.Errorf("ParseDER(%x) = %s, want %s", der, parsed, sig)
+			}
+
+			parsed, hashType, err := ParseSignatureWithHashType(append(der, 0x01))
+			if err != nil || hashType != 0x01 || !parsed.R().Equal(sig.R()) {
+				t.Errorf("ParseSignatureWithHashType(%x01) = %s, %d, %v", der, parsed, hashType, err)
+			}
+		}
+	}
+}
+
+func TestParseDERErrors(t *testing.T) {
+	one := []byte{0x01}
+	valid := derSig(one, one)
+	n := BitcoinN().Bytes()
+
+	cases := []struct {
+		name string
+		der  []byte
+		err  error
+	}{
+		{"too short", valid[:7], ErrDERTooShort},
+		{"too long", derSig(make([]byte, 34), make([]byte, 33)), ErrDERTooLong},
+		{"sequence marker", append([]byte{0x31}, valid[1:]...), ErrDERInvalidSequence},
+		{"total length", append([]byte{0x30, 0x07}, valid[2:]...), ErrDERInvalidLength},
+		{"r length past the end", []byte{0x30, 0x06, 0x02, 0x05, 0x01, 0x02, 0x01, 0x01}, ErrDERInvalidLength},
+		{"s length", []byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x02, 0x01}, ErrDERInvalidLength},
+		{"r marker", []byte{0x30, 0x06, 0x03, 0x01, 0x01, 0x02, 0x01, 0x01}, ErrDERInvalidIntMarker},
+		{"s marker", []byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x03, 0x01, 0x01}, ErrDERInvalidIntMarker},
+		{"empty r", derSig(nil, []byte{0x01, 0x01}), ErrDERZeroLengthInt},
+		{"empty s", derSig([]byte{0x01, 0x01}, nil), ErrDERZeroLengthInt},
+		{"negative r", derSig([]byte{0x80}, one), ErrDERNegativeInt},
+		{"negative s", derSig(one, []byte{0xff, 0x01}), ErrDERNegativeInt},
+		{"padded r", derSig([]byte{0x00, 0x01}, one), ErrDERPaddedInt},
+		{"padded s", derSig(one, []byte{0x00, 0x7f}), ErrDERPaddedInt},
+		{"zero r", derSig([]byte{0x00}, one), ErrDERIntOutOfRange},
+		{"s = n", derSig(one, append([]byte{0x00}, n...)), ErrDERIntOutOfRange},
+	}
+	for _, c := range cases {
+		if _, err := ParseDER(c.der); !errors.Is(err, c.err) {
+			t.Errorf("%s: ParseDER(%x) = %v, want %v", c.name, c.der, err, c.err)
+		}
+	}
+
+	if _, _, err := ParseSignatureWithHashType(nil); err != ErrDERTooShort {
+		t.Errorf("empty signature: %v, want ErrDERTooShort", err)
+	}
+	if _, _, err := ParseSignatureWithHashType(append(derSig([]byte{0x80}, one), 0x01)); !errors.Is(err, ErrDERNegativeInt) {
+		t.Errorf("negative r with hash type: %v, want ErrDERNegativeInt", err)
+	}
+	if !bytes.Equal(valid, NewSignature(Secp256k1().scalar(big.NewInt(1)), Secp256k1().scalar(big.NewInt(1))).MustDER()) {
+		t.Errorf("DER(1, 1) = %x", valid)
+	}
+}
+
+func TestDERZero(t *testing.T) {
+	c := Secp256k1()
+	one, zero := c.scalar(big.NewInt(1)), c.scalar(big.NewInt(0))
+
+	for _, sig := range []*Signature{NewSignature(zero, one), NewSignature(one, zero)} {
+		if _, err := sig.DER(); err != ErrDERZeroLengthInt {
+			t.Errorf("DER of r = %s, s = %s: %v, want ErrDERZeroLengthInt", sig.r.num, sig.s.num, err)
+		}
+		mustPanic(t, "MustDER", ErrDERZeroLengthInt, func() {
+			sig.MustDER()
+		})
+	}
+}
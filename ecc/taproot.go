@@ -0,0 +1,96 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+/*
+BIP341 taproot key tweaking
+
+The output key committed in a P2TR output is the internal key P (x-only, so
+the even y one) moved by a tweak that commits to the script tree:
+	t = hash_TapTweak(x(P) || merkle root), no merkle root when there are no scripts
+	Q = P + t*G
+To spend with the key path we sign for Q with the tweaked private key
+	d = d if P.y is even, n - d otherwise
+	d' = d + t mod n
+SignSchnorr takes care of the parity of Q itself.
+*/
+
+var (
+	ErrTaprootTweakOutOfRange = errors.New("taproot tweak is not less than the group order")
+	ErrTaprootTweakInfinity   = errors.New("taproot tweaked key is the identity point")
+	ErrTaprootMerkleRoot      = errors.New("taproot merkle root must be empty or 32 bytes")
+)
+
+// TaprootTweak returns the output key Q for this internal key, merkleRoot is
+// nil for a key path only output
+func (p *Point) TaprootTweak(merkleRoot []byte) (*Point, error) {
+	if p.curve != Secp256k1() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	if p.x == nil {
+		return nil, ErrInvalidXOnlyKey
+	}
+
+	P, err := liftX(p.x.num)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := taprootTweakScalar(P, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	Q := baseMulJacobian(t.num).add(P.toJacobian()).toAffine()
+	if Q.x == nil {
+		return nil, ErrTaprootTweakInfinity
+	}
+
+	return Q, nil
+}
+
+// TaprootTweak returns the private key of the output key, see Point.TaprootTweak
+func (pk *PrivateKey) TaprootTweak(merkleRoot []byte) (*PrivateKey, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return nil, err
+	}
+
+	d := pk.d
+	if !pk.Q.hasEvenY() {
+		d = d.neg()
+	}
+
+	t, err := taprootTweakScalar(pk.Q, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	d = d.add(t)
+	if d.IsZero() {
+		return nil, ErrTaprootTweakInfinity
+	}
+
+	return newPrivateKey(d.num), nil
+}
+
+// TaprootTweakHash is hash_TapTweak(x(P) || merkle root)
+func TaprootTweakHash(internalKey *Point, merkleRoot []byte) []byte {
+	return TaggedHash("TapTweak", internalKey.XOnly(), merkleRoot)
+}
+
+func taprootTweakScalar(internalKey *Point, merkleRoot []byte) (*Scalar, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return nil, ErrTaprootMerkleRoot
+	}
+
+	t, err := Secp256k1().NewScalar(new(big.Int).SetBytes(TaprootTweakHash(internalKey, merkleRoot)))
+	if err != nil {
+		return nil, ErrTaprootTweakOutOfRange
+	}
+
+	return t, nil
+}
@@ -0,0 +1,150 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// scriptPubKey section of the BIP341 wallet-test-vectors.json
+var bip341ScriptPubKeyVectors = []struct {
+	internalPubkey string
+	merkleRoot     string
+	tweak          string
+	tweakedPubkey  string
+	address        string
+}{
+	{
+		internalPubkey: "d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+		tweak:          "b86e7be8f39bab32a6f2c0443abbc210f0edac0e2c53d501b36b64437d9c6c70",
+		tweakedPubkey:  "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+		address:        "bc1p2wsldez5mud2yam29q22wgfh9439spgduvct83k3pm50fcxa5dps59h4z5",
+	},
+	{
+		internalPubkey: "187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+		merkleRoot:     "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+		tweak:          "cbd8679ba636c1110ea247542cfbd964131a6be84f873f7f3b62a777528ed001",
+		tweakedPubkey:  "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+		address:        "bc1pz37fc4cn9ah8anwm4xqqhvxygjf9rjf2resrw8h8w4tmvcs0863sa2e586",
+	},
+	{
+		internalPubkey: "93478e9488f956df2396be2ce6c5cced75f900dfa18e7dabd2428aae78451820",
+		merkleRoot:     "c525714a7f49c28aedbbba78c005931a81c234b2f6c99a73e4d06082adc8bf2b",
+		tweak:          "6af9e28dbf9d6aaf027696e2598a5b3d056f5fd2355a7fd5a37a0e5008132d30",
+		tweakedPubkey:  "e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e",
+		address:        "bc1punvppl2stp38f7kwv2u2spltjuvuaayuqsthe34hd2dyy5w4g58qqfuag5",
+	},
+}
+
+func TestTaprootTweakBIP341(t *testing.T) {
+	for _, v := range bip341ScriptPubKeyVectors {
+		xOnly, _ := hex.DecodeString(v.internalPubkey)
+		merkleRoot, _ := hex.DecodeString(v.merkleRoot)
+
+		P, err := ParseXOnly(xOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tweak := hex.EncodeToString(TaprootTweakHash(P, merkleRoot)); tweak != v.tweak {
+			t.Errorf("%s: tweak %s, want %s", v.internalPubkey, tweak, v.tweak)
+		}
+
+		Q, err := P.TaprootTweak(merkleRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tweaked := hex.EncodeToString(Q.XOnly()); tweaked != v.tweakedPubkey {
+			t.Errorf("%s: tweaked key %s, want %s", v.internalPubkey, tweaked, v.tweakedPubkey)
+		}
+
+		if address, err := P.P2TRAddress(merkleRoot, false); err != nil || address != v.address {
+			t.Errorf("%s: address %s (%v), want %s", v.internalPubkey, address, err, v.address)
+		}
+	}
+}
+
+// keyPathSpending section of the BIP341 wallet-test-vectors.json
+func TestTaprootTweakPrivateKeyBIP341(t *testing.T) {
+	vectors := []struct {
+		internalPrivkey string
+		merkleRoot      string
+		tweakedPrivkey  string
+	}{
+		{
+			internalPrivkey: "6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa",
+			tweakedPrivkey:  "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9",
+		},
+	}
+
+	for _, v := range vectors {
+		merkleRoot, _ := hex.DecodeString(v.merkleRoot)
+		pk := MustPrivateKey(hexInt(v.internalPrivkey))
+
+		tweaked, err := pk.TaprootTweak(merkleRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(tweaked.d.Bytes()); got != v.tweakedPrivkey {
+			t.Errorf("%s: tweaked private key %s, want %s", v.internalPrivkey, got, v.tweakedPrivkey)
+		}
+	}
+}
+
+// a key path signature made with the tweaked private key verifies under the
+// output key computed from the internal public key alone
+func TestTaprootKeyPathSign(t *testing.T) {
+	merkleRoot := TaggedHash("TapLeaf", []byte{0xc0, 0x01, 0x51})
+	msg := TaggedHash("TapSighash", []byte("key path spend"))
+
+	// 6*G has an odd y, the others an even one
+	for _, secret := range []int64{1, 3, 6, 0xdeadbeef} {
+		pk := MustPrivateKey(big.NewInt(secret))
+
+		for _, root := range [][]byte{nil, merkleRoot} {
+			tweaked, err := pk.TaprootTweak(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Q, err := pk.Public().TaprootTweak(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tweaked.Public().XOnly(), Q.XOnly()) {
+				t.Errorf("secret %d: tweaked private key does not match the output key", secret)
+			}
+
+			sig, err := tweaked.SignSchnorr(msg, make([]byte, 32))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Q.VerifySchnorr(msg, sig) {
+				t.Errorf("secret %d, merkle root %x: key path signature does not verify", secret, root)
+			}
+
+			// and not under the untweaked key
+			if pk.Public().VerifySchnorr(msg, sig) {
+				t.Errorf("secret %d, merkle root %x: signature verifies under the internal key", secret, root)
+			}
+		}
+	}
+}
+
+func TestTaprootTweakErrors(t *testing.T) {
+	P := MustPrivateKey(big.NewInt(1)).Public()
+
+	if _, err := P.TaprootTweak(make([]byte, 31)); err != ErrTaprootMerkleRoot {
+		t.Errorf("31 byte merkle root: %v, want ErrTaprootMerkleRoot", err)
+	}
+	if _, err := MustPrivateKey(big.NewInt(1)).TaprootTweak(make([]byte, 33)); err != ErrTaprootMerkleRoot {
+		t.Errorf("33 byte merkle root: %v, want ErrTaprootMerkleRoot", err)
+	}
+	if _, err := Secp256k1().Identity().TaprootTweak(nil); err != ErrInvalidXOnlyKey {
+		t.Errorf("identity: %v, want ErrInvalidXOnlyKey", err)
+	}
+	if _, err := P256().Generator().TaprootTweak(nil); err != ErrUnsupportedCurve {
+		t.Errorf("P-256: %v, want ErrUnsupportedCurve", err)
+	}
+}
@@ -1,9 +1,12 @@
 package ecc
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"errors"
 	"golang.org/x/crypto/ripemd160"
 	"math/big"
+	"strings"
 )
 
 func Hash160(s []byte) []byte {
@@ -19,8 +22,15 @@ func Base58Checksum(s []byte) string {
 	return EncodeBase58(append(s, hash256[:4]...))
 }
 
+const BASE58_ALPHABET = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	ErrBase58InvalidChar = errors.New("invalid base58 character")
+	ErrBase58Checksum    = errors.New("base58 checksum mismatch")
+	ErrBase58TooShort    = errors.New("base58check string is too short")
+)
+
 func EncodeBase58(s []byte) string {
-	const BASE58_ALPHABET = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
 	count := 0
 	divFactor := big.NewInt(58)
 
@@ -50,38 +60,152 @@ func EncodeBase58(s []byte) string {
 	return prefix + result
 }
 
-func ParseSEC(secBin []byte) *Point {
-	if secBin[0] == 4 {
-		// uncompressed
-		x := new(big.Int)
-		x.SetBytes(secBin[1:33])
-		y := new(big.Int)
-		y.SetBytes(secBin[33:65])
+// DecodeBase58 is the inverse of EncodeBase58, every leading '1' is a zero byte
+func DecodeBase58(s string) ([]byte, error) {
+	count := 0
+	for count < len(s) && s[count] == '1' {
+		count++
+	}
 
-		return S256Point(x, y)
+	num := new(big.Int)
+	base := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(BASE58_ALPHABET, s[i])
+		if digit < 0 {
+			return nil, ErrBase58InvalidChar
+		}
+		num.Mul(num, base)
+		num.Add(num, big.NewInt(int64(digit)))
 	}
 
-	isEven := (secBin[0] == 2)
-	x := new(big.Int)
-	x.SetBytes(secBin[1:])
-	y2 := S256Field(x).Power(big.NewInt(3)).Add(S256Field(big.NewInt(7)))
-	y := y2.Sqrt()
-	var yEven *FieldElement
-	var yOdd *FieldElement
-
-	if new(big.Int).Mod(y.num, big.NewInt(2)).Cmp(big.NewInt(0)) == 0 {
-		yEven = y
-		yOdd = y.Negate()
-	} else {
-		yOdd = y
-		yEven = y.Negate()
+	return append(make([]byte, count), num.Bytes()...), nil
+}
+
+// DecodeBase58Checksum decodes s and checks its last 4 bytes against Hash256 of the rest
+func DecodeBase58Checksum(s string) ([]byte, error) {
+	decoded, err := DecodeBase58(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) < 4 {
+		return nil, ErrBase58TooShort
 	}
 
-	if isEven {
-		return S256Point(x, yEven.num)
-	} else {
-		return S256Point(x, yOdd.num)
+	payload := decoded[:len(decoded)-4]
+	if !bytes.Equal(Hash256(string(payload))[:4], decoded[len(decoded)-4:]) {
+		return nil, ErrBase58Checksum
 	}
+
+	return payload, nil
+}
+
+// Errors returned by ParseSEC
+var (
+	ErrSECEmpty          = errors.New("sec public key is empty")
+	ErrSECPrefix         = errors.New("sec public key prefix must be 0x02, 0x03 or 0x04")
+	ErrSECLength         = errors.New("sec public key length does not match its prefix (33 bytes compressed, 65 bytes uncompressed)")
+	ErrSECHybrid         = errors.New("hybrid sec public keys (0x06, 0x07) are not allowed")
+	ErrSECHybridParity   = errors.New("hybrid sec public key prefix does not match the parity of y")
+	ErrSECXOutOfRange    = errors.New("sec public key x is not less than the field size")
+	ErrSECYOutOfRange    = errors.New("sec public key y is not less than the field size")
+	ErrSECNotOnCurve     = errors.New("sec public key is not on the curve")
+	ErrSECNoSquareRoot   = errors.New("sec public key x has no y on the curve")
+	ErrSECInfinityPrefix = errors.New("sec public key cannot encode the identity point")
+)
+
+/*
+ParseSEC is the reverse of SEC
+
+	0x02 / 0x03 || x (32 bytes), y is the even / odd square root of x^3 + 7
+	0x04 || x (32 bytes) || y (32 bytes)
+
+x and y have to be less than p and the point has to be on the curve.
+Hybrid keys (0x06 / 0x07 || x || y, the prefix repeats the parity of y) are
+only accepted by ParseSECAllowHybrid.
+Keys of other curves are parsed with Curve.ParseSEC.
+*/
+func ParseSEC(secBin []byte) (*Point, error) {
+	return Secp256k1().parseSEC(secBin, false)
+}
+
+// ParseSECAllowHybrid is ParseSEC that also accepts the hybrid 0x06 / 0x07 encoding
+func ParseSECAllowHybrid(secBin []byte) (*Point, error) {
+	return Secp256k1().parseSEC(secBin, true)
+}
+
+// ParseSEC parses a SEC public key of this curve, x and y take as many bytes as p
+func (c *Curve) ParseSEC(secBin []byte) (*Point, error) {
+	return c.parseSEC(secBin, false)
+}
+
+func (c *Curve) parseSEC(secBin []byte, allowHybrid bool) (*Point, error) {
+	if len(secBin) == 0 {
+		return nil, ErrSECEmpty
+	}
+
+	size := c.coordinateSize()
+
+	switch secBin[0] {
+	case 0x00:
+		return nil, ErrSECInfinityPrefix
+	case 0x02, 0x03:
+		if len(secBin) != 1+size {
+			return nil, ErrSECLength
+		}
+	case 0x04:
+		if len(secBin) != 1+2*size {
+			return nil, ErrSECLength
+		}
+	case 0x06, 0x07:
+		if !allowHybrid {
+			return nil, ErrSECHybrid
+		}
+		if len(secBin) != 1+2*size {
+			return nil, ErrSECLength
+		}
+	default:
+		return nil, ErrSECPrefix
+	}
+
+	x := new(big.Int).SetBytes(secBin[1 : 1+size])
+	if x.Cmp(c.p) >= 0 {
+		return nil, ErrSECXOutOfRange
+	}
+
+	// y^2 = x^3 + ax + b
+	xField := c.field(x)
+	y2 := xField.Power(big.NewInt(3)).add(c.aField().mul(xField)).add(c.bField())
+
+	if len(secBin) == 1+2*size {
+		// uncompressed or hybrid
+		y := new(big.Int).SetBytes(secBin[1+size:])
+		if y.Cmp(c.p) >= 0 {
+			return nil, ErrSECYOutOfRange
+		}
+
+		if !c.field(y).Power(big.NewInt(2)).EqualTo(y2) {
+			return nil, ErrSECNotOnCurve
+		}
+
+		if secBin[0] != 0x04 && uint(secBin[0]&1) != y.Bit(0) {
+			return nil, ErrSECHybridParity
+		}
+
+		return &Point{curve: c, a: c.aField(), b: c.bField(), x: c.field(x), y: c.field(y)}, nil
+	}
+
+	// ModSqrt works for every p, not only p % 4 == 3 like FieldElement.Sqrt
+	y := new(big.Int).ModSqrt(y2.num, c.p)
+	if y == nil {
+		return nil, ErrSECNoSquareRoot
+	}
+
+	if uint(secBin[0]&1) != y.Bit(0) {
+		y.Sub(c.p, y).Mod(y, c.p)
+	}
+
+	return &Point{curve: c, a: c.aField(), b: c.bField(), x: c.field(x), y: c.field(y)}, nil
 }
 
 // Hash it 2 times to reduce the risk
@@ -93,17 +217,23 @@ func Hash256(text string) []byte {
 	return hashTwice[:]
 }
 
+// TaggedHash is the BIP340 hash for a given context
+// sha256(sha256(tag) || sha256(tag) || msg)
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	hasher.Write(tagHash[:])
+	hasher.Write(tagHash[:])
+	for _, msg := range msgs {
+		hasher.Write(msg)
+	}
+	return hasher.Sum(nil)
+}
+
 func GeneratorPoint() *Point {
-	Gx := new(big.Int)
-	Gx.SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
-	Gy := new(big.Int)
-	Gy.SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
-	G := S256Point(Gx, Gy)
-	return G
+	return Secp256k1().Generator()
 }
 
 func BitcoinN() *big.Int {
-	n := new(big.Int)
-	n.SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
-	return n
+	return Secp256k1().N()
 }
@@ -0,0 +1,138 @@
+package ecc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestParseSECRoundTrip(t *testing.T) {
+	for _, c := range []*Curve{Secp256k1(), P256()} {
+		for _, k := range []int64{1, 2, 3, 7, 1 << 40} {
+			P := c.Generator().ScalarMul(big.NewInt(k))
+			for _, compressed := range []bool{true, false} {
+				_, sec := P.SEC(compressed)
+				got, err := c.ParseSEC(sec)
+				if err != nil || !got.Equal(P) {
+					t.Errorf("%s: ParseSEC(%x) = %v, %v, want %s", c.Name(), sec, got, err, P)
+				}
+			}
+		}
+	}
+}
+
+func TestParseSECErrors(t *testing.T) {
+	G := GeneratorPoint()
+	p := secp256k1P()
+	x := G.x.num.FillBytes(make([]byte, 32))
+	y := G.y.num.FillBytes(make([]byte, 32))
+	sec := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	// G.y is even, a hybrid G has the prefix 0x06
+	cases := []struct {
+		name  string
+		sec   []byte
+		allow bool
+		err   error
+	}{
+		{"empty", nil, false, ErrSECEmpty},
+		{"identity prefix", []byte{0x00}, false, ErrSECInfinityPrefix},
+		{"unknown prefix", sec([]byte{0x05}, x), false, ErrSECPrefix},
+		{"compressed too long", sec([]byte{0x02}, x, y), false, ErrSECLength},
+		{"uncompressed too short", sec([]byte{0x04}, x), false, ErrSECLength},
+		{"hybrid", sec([]byte{0x06}, x, y), false, ErrSECHybrid},
+		{"hybrid allowed", sec([]byte{0x06}, x, y), true, nil},
+		{"hybrid too short", sec([]byte{0x06}, x), true, ErrSECLength},
+		{"hybrid parity", sec([]byte{0x07}, x, y), true, ErrSECHybridParity},
+		{"x = p", sec([]byte{0x02}, p.FillBytes(make([]byte, 32))), false, ErrSECXOutOfRange},
+		{"y = p", sec([]byte{0x04}, x, p.FillBytes(make([]byte, 32))), false, ErrSECYOutOfRange},
+		{"not on curve", sec([]byte{0x04}, x, new(big.Int).Add(G.y.num, big.NewInt(1)).FillBytes(make([]byte, 32))), false, ErrSECNotOnCurve},
+		// 5^3 + 7 is not a square mod p
+		{"no square root", sec([]byte{0x03}, big.NewInt(5).FillBytes(make([]byte, 32))), false, ErrSECNoSquareRoot},
+	}
+	for _, c := range cases {
+		parse := ParseSEC
+		if c.allow {
+			parse = ParseSECAllowHybrid
+		}
+
+		P, err := parse(c.sec)
+		if err != c.err {
+			t.Errorf("%s: %v, want %v", c.name, err, c.err)
+		}
+		if err == nil && !P.Equal(G) {
+			t.Errorf("%s: %s, want G", c.name, P)
+		}
+	}
+}
+
+// Bitcoin Core base58_encode_decode.json
+var base58Vectors = []struct {
+	hex     string
+	encoded string
+}{
+	{"", ""},
+	{"61", "2g"},
+	{"626262", "a3gV"},
+	{"636363", "aPEr"},
+	{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+	{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+	{"516b6fcd0f", "ABnLTmg"},
+	{"bf4f89001e670274dd", "3SEo3LWLoPntC"},
+	{"572e4794", "3EFU7m"},
+	{"ecac89cad93923c02321", "EJDM8drfXA6uyA"},
+	{"10c8511e", "Rt5zm"},
+	{"00000000000000000000", "1111111111"},
+	{"000111d38e5fc9071ffcd20b4a763cc9ae4f252bb4e48fd66a835e252ada93ff480d6dd43dc62a641155a5", "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"},
+}
+
+func TestBase58Vectors(t *testing.T) {
+	for _, v := range base58Vectors {
+		data, _ := hex.DecodeString(v.hex)
+
+		if got := EncodeBase58(data); got != v.encoded {
+			t.Errorf("EncodeBase58(%s) = %s, want %s", v.hex, got, v.encoded)
+		}
+
+		// every leading '1' comes back as a zero byte
+		decoded, err := DecodeBase58(v.encoded)
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Errorf("DecodeBase58(%s) = %x, %v, want %s", v.encoded, decoded, err, v.hex)
+		}
+	}
+
+	for _, s := range []string{"0", "O", "I", "l", "3mJr0", "1 2", "é"} {
+		if _, err := DecodeBase58(s); err != ErrBase58InvalidChar {
+			t.Errorf("DecodeBase58(%q) = %v, want ErrBase58InvalidChar", s, err)
+		}
+	}
+}
+
+func TestDecodeBase58Checksum(t *testing.T) {
+	payload := []byte{0x00, 0x00, 0x01, 0x02}
+	encoded := Base58Checksum(payload)
+
+	decoded, err := DecodeBase58Checksum(encoded)
+	if err != nil || !bytes.Equal(decoded, payload) {
+		t.Errorf("DecodeBase58Checksum(%s) = %x, %v, want %x", encoded, decoded, err, payload)
+	}
+	if encoded[:2] != "11" {
+		t.Errorf("Base58Checksum(%x) = %s, want two leading 1s", payload, encoded)
+	}
+
+	// flipping a bit of the payload breaks the checksum
+	broken := append([]byte{0x00, 0x00, 0x01, 0x03}, Hash256(string(payload))[:4]...)
+	if _, err := DecodeBase58Checksum(EncodeBase58(broken)); err != ErrBase58Checksum {
+		t.Errorf("bad checksum: %v, want ErrBase58Checksum", err)
+	}
+
+	if _, err := DecodeBase58Checksum("111"); err != ErrBase58TooShort {
+		t.Errorf("3 bytes: %v, want ErrBase58TooShort", err)
+	}
+	if _, err := DecodeBase58Checksum("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAM0"); err != ErrBase58InvalidChar {
+		t.Errorf("invalid character: %v, want ErrBase58InvalidChar", err)
+	}
+}
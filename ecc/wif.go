@@ -0,0 +1,85 @@
+package ecc
+
+import (
+	"errors"
+	"math/big"
+)
+
+/*
+Wallet Import Format
+	prefix (0x80 mainnet, 0xef testnet) || 32 bytes secret || 0x01 if the public key is compressed
+encoded with Base58Checksum
+*/
+
+var (
+	ErrWIFLength           = errors.New("wif must hold 32 bytes secret with an optional compressed flag")
+	ErrWIFPrefix           = errors.New("wif prefix is neither mainnet (0x80) nor testnet (0xef)")
+	ErrWIFCompressedFlag   = errors.New("wif compressed flag must be 0x01")
+	ErrWIFSecretOutOfRange = errors.New("wif secret must be between 1 and n - 1")
+)
+
+// WIF encodes a secp256k1 private key, ParseWIF always reads the secret back
+// as a secp256k1 one so keys of other curves are refused
+func (pk *PrivateKey) WIF(compressed bool, testnet bool) (string, error) {
+	if err := pk.checkSecp256k1(); err != nil {
+		return "", err
+	}
+
+	prefix := byte(0x80)
+	if testnet {
+		prefix = 0xef
+	}
+
+	payload := make([]byte, 33, 34)
+	payload[0] = prefix
+	pk.d.num.FillBytes(payload[1:])
+
+	if compressed {
+		payload = append(payload, 0x01)
+	}
+
+	return Base58Checksum(payload), nil
+}
+
+// MustWIF is WIF that panics when the key is not a secp256k1 one
+func (pk *PrivateKey) MustWIF(compressed bool, testnet bool) string {
+	wif, err := pk.WIF(compressed, testnet)
+	if err != nil {
+		panic(err)
+	}
+	return wif
+}
+
+// ParseWIF returns the private key with the compressed and testnet flags stored in wif
+func ParseWIF(wif string) (pk *PrivateKey, compressed bool, testnet bool, err error) {
+	payload, err := DecodeBase58Checksum(wif)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	switch len(payload) {
+	case 33:
+	case 34:
+		if payload[33] != 0x01 {
+			return nil, false, false, ErrWIFCompressedFlag
+		}
+		compressed = true
+	default:
+		return nil, false, false, ErrWIFLength
+	}
+
+	switch payload[0] {
+	case 0x80:
+	case 0xef:
+		testnet = true
+	default:
+		return nil, false, false, ErrWIFPrefix
+	}
+
+	secret := new(big.Int).SetBytes(payload[1:33])
+	if secret.Sign() == 0 || secret.Cmp(BitcoinN()) >= 0 {
+		return nil, false, false, ErrWIFSecretOutOfRange
+	}
+
+	return newPrivateKey(secret), compressed, testnet, nil
+}
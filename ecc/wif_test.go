@@ -0,0 +1,80 @@
+package ecc
+
+import (
+	"math/big"
+	"testing"
+)
+
+var wifVectors = []struct {
+	secret     string
+	compressed bool
+	testnet    bool
+	wif        string
+}{
+	{"1", true, false, "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"},
+	{"1", false, false, "5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf"},
+	{"1", true, true, "cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA"},
+	{"1", false, true, "91avARGdfge8E4tZfYLoxeJ5sGBdNJQH4kvjJoQFacbgwmaKkrx"},
+}
+
+func TestWIFVectors(t *testing.T) {
+	for _, v := range wifVectors {
+		pk := MustPrivateKey(hexInt(v.secret))
+
+		wif, err := pk.WIF(v.compressed, v.testnet)
+		if err != nil || wif != v.wif {
+			t.Errorf("WIF(%s, %v, %v) = %s, %v, want %s", v.secret, v.compressed, v.testnet, wif, err, v.wif)
+		}
+
+		parsed, compressed, testnet, err := ParseWIF(v.wif)
+		if err != nil {
+			t.Fatalf("ParseWIF(%s): %v", v.wif, err)
+		}
+		if parsed.d.num.Cmp(pk.d.num) != 0 || compressed != v.compressed || testnet != v.testnet {
+			t.Errorf("ParseWIF(%s) = %x, %v, %v", v.wif, parsed.d.num, compressed, testnet)
+		}
+	}
+}
+
+func TestParseWIFErrors(t *testing.T) {
+	secret := big.NewInt(1).FillBytes(make([]byte, 32))
+	payload := func(prefix byte, secret []byte, suffix ...byte) string {
+		return Base58Checksum(append(append([]byte{prefix}, secret...), suffix...))
+	}
+
+	valid := wifVectors[0].wif
+	badChecksum := valid[:len(valid)-1] + "o"
+	if valid[len(valid)-1] == 'o' {
+		badChecksum = valid[:len(valid)-1] + "p"
+	}
+
+	cases := []struct {
+		name string
+		wif  string
+		err  error
+	}{
+		{"checksum", badChecksum, ErrBase58Checksum},
+		{"prefix", payload(0x81, secret), ErrWIFPrefix},
+		{"compressed flag", payload(0x80, secret, 0x02), ErrWIFCompressedFlag},
+		{"too short", payload(0x80, secret[1:]), ErrWIFLength},
+		{"too long", payload(0x80, secret, 0x01, 0x01), ErrWIFLength},
+		{"zero secret", payload(0x80, make([]byte, 32), 0x01), ErrWIFSecretOutOfRange},
+		{"secret n", payload(0x80, BitcoinN().Bytes()), ErrWIFSecretOutOfRange},
+	}
+	for _, c := range cases {
+		if _, _, _, err := ParseWIF(c.wif); err != c.err {
+			t.Errorf("%s: ParseWIF(%s) = %v, want %v", c.name, c.wif, err, c.err)
+		}
+	}
+}
+
+func TestWIFOtherCurve(t *testing.T) {
+	pk, err := P256().NewPrivateKey(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pk.WIF(true, false); err != ErrUnsupportedCurve {
+		t.Errorf("P-256 key: %v, want ErrUnsupportedCurve", err)
+	}
+}
@@ -2,4 +2,4 @@ module transaction
 
 go 1.23.8
 
-require github.com/tsuna/endian v0.0.0-20250821203744-206f48965e13 // indirect
+require github.com/tsuna/endian v0.0.0-20250821203744-206f48965e13